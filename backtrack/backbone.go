@@ -0,0 +1,98 @@
+package backtrack
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+)
+
+// Backbone holds the values every coordinate has in at least one solution of
+// a sudoku, also known as its true candidates.
+type Backbone struct {
+	// Candidates holds the true candidates of every coordinate in ascending
+	// order. All lists are empty if the sudoku has no solution.
+	Candidates map[sudoku.Coordinate][]int
+}
+
+// Fixed returns the value of the coordinate if it has the same value in all
+// solutions.
+func (b Backbone) Fixed(coordinate sudoku.Coordinate) (int, bool) {
+	candidates := b.Candidates[coordinate]
+	if len(candidates) != 1 {
+		return 0, false
+	}
+	return candidates[0], true
+}
+
+// FindBackbone computes the true candidates of every coordinate of the
+// sudoku. It searches one solution and then, for every value that wasn't
+// part of a solution found so far, a solution with the value fixed at the
+// coordinate. Every solution found that way is a witness for all of its
+// values, so only a fraction of the values have to be searched. It returns
+// the error of the context if the context is cancelled before all values were
+// decided.
+func FindBackbone(ctx context.Context, mode Mode, sudok sudoku.Sudoku) (Backbone, error) {
+	witnessed := make(map[sudoku.Coordinate]map[int]bool, len(sudok.Coordinates))
+	for _, coord := range sudok.Coordinates {
+		witnessed[coord] = make(map[int]bool, len(sudok.PossibleValues))
+	}
+	// findWitness searches a solution of the sudoku with the additional
+	// constraints and marks its values. It returns false if there is none.
+	findWitness := func(additional ...sudoku.Constraint) (bool, error) {
+		restricted := sudok
+		restricted.Constraints = append(slices.Clip(sudok.Constraints), additional...)
+		found := false
+		err := search(ctx, mode, restricted, func(solution sudoku.Solution) bool {
+			found = true
+			for _, coord := range sudok.Coordinates {
+				if value, ok := solution.Get(coord); ok {
+					witnessed[coord][value] = true
+				}
+			}
+			return false
+		})
+		if err != nil {
+			return false, err
+		}
+		if !found && ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return found, nil
+	}
+
+	backbone := Backbone{Candidates: make(map[sudoku.Coordinate][]int, len(sudok.Coordinates))}
+	for _, coord := range sudok.Coordinates {
+		backbone.Candidates[coord] = []int{}
+	}
+	solvable, err := findWitness()
+	if err != nil {
+		return Backbone{}, err
+	}
+	if !solvable {
+		return backbone, nil
+	}
+	// values that are removed before the search starts are in no solution
+	canBe := func(sudoku.Coordinate, int) bool { return true }
+	if root, err := mode.rootCandidate(sudok); err == nil {
+		if domains, ok := root.(sudoku.Domains); ok {
+			canBe = domains.CanBe
+		}
+	}
+	for _, coord := range sudok.Coordinates {
+		for _, value := range sudok.PossibleValues {
+			if witnessed[coord][value] || !canBe(coord, value) {
+				continue
+			}
+			if _, err := findWitness(constraint.FixedValueConstraint{Coordinate: coord, Value: value}); err != nil {
+				return Backbone{}, err
+			}
+		}
+		for value := range witnessed[coord] {
+			backbone.Candidates[coord] = append(backbone.Candidates[coord], value)
+		}
+		sort.Ints(backbone.Candidates[coord])
+	}
+	return backbone, nil
+}
@@ -2,6 +2,7 @@ package backtrack
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sudoku-solver/sudoku"
 )
@@ -11,24 +12,146 @@ type Candidate interface {
 	NextCandidates() []Candidate
 }
 
+// engine is implemented by modes that don't search a tree of candidates but
+// run their own search, e.g. on a single mutable state.
+type engine interface {
+	// search calls found for every solution until found returns false or the
+	// context is cancelled. The solution is only valid during the call.
+	search(ctx context.Context, found func(sudoku.Solution) bool)
+}
+
+// incrementalCandidate is implemented by candidates that know which
+// coordinates were filled in since their parent candidate. Since the parent
+// was checked already, only the constraints of these coordinates have to be
+// checked.
+type incrementalCandidate interface {
+	// filledIn returns the coordinates that were filled in since the parent
+	// and false if the candidate has no parent.
+	filledIn() ([]sudoku.Coordinate, bool)
+}
+
+// violationChecker checks candidates against the constraints of a sudoku. It
+// only checks the constraints of the filled in coordinates of incremental
+// candidates and all constraints for other candidates.
+type violationChecker struct {
+	sudok sudoku.Sudoku
+	index *sudoku.ConstraintIndex
+}
+
+func newViolationChecker(sudok sudoku.Sudoku) violationChecker {
+	return violationChecker{
+		sudok: sudok,
+		index: sudok.ConstraintIndex(),
+	}
+}
+
+func (v violationChecker) isViolated(candidate Candidate) bool {
+	if v.sudok.RegionCount > 0 {
+		// regions can be violated by any assignment, so they are always
+		// checked completely
+		return v.sudok.IsViolated(candidate)
+	}
+	if incremental, ok := candidate.(incrementalCandidate); ok {
+		if filled, ok := incremental.filledIn(); ok {
+			return v.index.IsViolatedAt(candidate, filled...)
+		}
+	}
+	return v.sudok.IsViolated(candidate)
+}
+
+// isSolved returns true if the solution has a value for every coordinate. It
+// must only be called for solutions that were checked with isViolated.
+func (v violationChecker) isSolved(solution sudoku.Solution) bool {
+	if v.sudok.RegionCount > 0 {
+		return v.sudok.IsSolved(solution)
+	}
+	for _, coord := range v.sudok.Coordinates {
+		if _, ok := solution.Get(coord); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// errNoSolution is wrapped by errors of the root candidate if the givens of
+// the sudoku contradict each other.
+var errNoSolution = errors.New("sudoku has no solution")
+
 type backtracker struct {
-	sudok     sudoku.Sudoku
-	solutions chan sudoku.Solution
+	checker violationChecker
+	// found is called for every solution and returns false if the search
+	// should stop
+	found func(sudoku.Solution) bool
 }
 
 // FindSolutions returns a channel that will be closed when all solutions have been found
-// or when the context is cancelled.
-func FindSolutions(ctx context.Context, mode Mode, sudok sudoku.Sudoku) <-chan sudoku.Solution {
-	root := mode.RootCandidate(sudok)
+// or when the context is cancelled. It returns an error if the mode can't solve the
+// sudoku.
+func FindSolutions(ctx context.Context, mode Mode, sudok sudoku.Sudoku) (<-chan sudoku.Solution, error) {
+	solutions := make(chan sudoku.Solution, 1)
+	e, err := mode.engine(sudok)
+	if errors.Is(err, errNoSolution) {
+		close(solutions)
+		return solutions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e != nil {
+		return findSolutionsWithEngine(ctx, e, sudok), nil
+	}
+	root, err := mode.rootCandidate(sudok)
+	if errors.Is(err, errNoSolution) {
+		close(solutions)
+		return solutions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 	b := &backtracker{
-		sudok:     sudok,
-		solutions: make(chan sudoku.Solution, 1),
+		checker: newViolationChecker(sudok),
+		found: func(solution sudoku.Solution) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case solutions <- solution:
+				return true
+			}
+		},
 	}
 	go func() {
-		defer close(b.solutions)
+		defer close(solutions)
 		b.backtrack(ctx, root)
 	}()
-	return b.solutions
+	return solutions, nil
+}
+
+func findSolutionsWithEngine(ctx context.Context, e engine, sudok sudoku.Sudoku) <-chan sudoku.Solution {
+	solutions := make(chan sudoku.Solution, 1)
+	go func() {
+		defer close(solutions)
+		e.search(ctx, func(solution sudoku.Solution) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case solutions <- snapshotSolution(sudok, solution):
+				return true
+			}
+		})
+	}()
+	return solutions
+}
+
+// snapshotSolution copies the values of the solution, so that it stays valid
+// when the engine that found it continues its search.
+func snapshotSolution(sudok sudoku.Sudoku, solution sudoku.Solution) sudoku.Solution {
+	values := make(map[sudoku.Coordinate]int, len(sudok.Coordinates))
+	for _, coord := range sudok.Coordinates {
+		if value, ok := solution.Get(coord); ok {
+			values[coord] = value
+		}
+	}
+	return sudoku.MapSolution(values)
 }
 
 // FindSolution returns the first solution found or an error if no solution was found or
@@ -36,7 +159,10 @@ func FindSolutions(ctx context.Context, mode Mode, sudok sudoku.Sudoku) <-chan s
 func FindSolution(ctx context.Context, mode Mode, sudok sudoku.Sudoku) (sudoku.Solution, error) {
 	subCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	solutions := FindSolutions(subCtx, mode, sudok)
+	solutions, err := FindSolutions(subCtx, mode, sudok)
+	if err != nil {
+		return nil, err
+	}
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -48,21 +174,73 @@ func FindSolution(ctx context.Context, mode Mode, sudok sudoku.Sudoku) (sudoku.S
 	}
 }
 
-func (b *backtracker) backtrack(ctx context.Context, candidate Candidate) {
+// CountSolutions counts the solutions of the sudoku without sending them
+// anywhere. It stops once limit solutions were found and returns true in that
+// case. A limit of zero or less counts all solutions. If the context is
+// cancelled, it returns the solutions counted so far and the error of the
+// context.
+func CountSolutions(ctx context.Context, mode Mode, sudok sudoku.Sudoku, limit int) (int, bool, error) {
+	count := 0
+	err := search(ctx, mode, sudok, func(sudoku.Solution) bool {
+		count++
+		return limit <= 0 || count < limit
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	limitHit := limit > 0 && count >= limit
+	if !limitHit && ctx.Err() != nil {
+		return count, false, ctx.Err()
+	}
+	return count, limitHit, nil
+}
+
+// search calls found for every solution of the sudoku until found returns
+// false or the context is cancelled. The solutions are only valid during the
+// call to found.
+func search(ctx context.Context, mode Mode, sudok sudoku.Sudoku, found func(sudoku.Solution) bool) error {
+	e, err := mode.engine(sudok)
+	if errors.Is(err, errNoSolution) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if e != nil {
+		e.search(ctx, found)
+		return nil
+	}
+	root, err := mode.rootCandidate(sudok)
+	if errors.Is(err, errNoSolution) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b := &backtracker{
+		checker: newViolationChecker(sudok),
+		found:   found,
+	}
+	b.backtrack(ctx, root)
+	return nil
+}
+
+// backtrack searches the subtree of the candidate depth first. It returns
+// false if the search should stop.
+func (b *backtracker) backtrack(ctx context.Context, candidate Candidate) bool {
 	if ctx.Err() != nil {
-		return
+		return false
 	}
-	if b.sudok.IsViolated(candidate) {
-		return
+	if b.checker.isViolated(candidate) {
+		return true
 	}
-	if b.sudok.IsSolved(candidate) {
-		select {
-		case <-ctx.Done():
-		case b.solutions <- candidate:
-		}
-		return
+	if b.checker.isSolved(candidate) {
+		return b.found(candidate)
 	}
 	for _, nextCandidate := range candidate.NextCandidates() {
-		b.backtrack(ctx, nextCandidate)
+		if !b.backtrack(ctx, nextCandidate) {
+			return false
+		}
 	}
+	return true
 }
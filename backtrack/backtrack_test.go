@@ -16,9 +16,20 @@ func modesToTest() []backtrack.Mode {
 	return []backtrack.Mode{
 		backtrack.ModeSimple,
 		backtrack.ModePencilMark,
+		backtrack.ModePencilMarkMRV,
+		backtrack.ModeTrail,
+		backtrack.ModeGAC,
+		backtrack.ModeSAT,
+		backtrack.ModeNogood,
 	}
 }
 
+// exactCoverModesToTest returns the modes that can only solve sudokus without
+// variant constraints in addition to the modes that can solve all sudokus.
+func exactCoverModesToTest() []backtrack.Mode {
+	return append(modesToTest(), backtrack.ModeDLX)
+}
+
 func readSudokuStr(t require.TestingT, s string) sudoku.Sudoku {
 	sud, err := sudokuio.ParseString(s)
 	require.NoError(t, err)
@@ -69,6 +80,32 @@ func createClassicSudoku(t require.TestingT) (sudoku.Sudoku, sudoku.Solution) {
 	return sudok, solution
 }
 
+// ambiguousClassicSudoku returns the classic sudoku without its first three
+// givens, which leaves it with several hundred solutions.
+func ambiguousClassicSudoku(t require.TestingT) sudoku.Sudoku {
+	sudok, _ := createClassicSudoku(t)
+	removed := 0
+	constraints := make([]sudoku.Constraint, 0, len(sudok.Constraints))
+	for _, constr := range sudok.Constraints {
+		if _, ok := constr.(constraint.FixedValueConstraint); ok && removed < 3 {
+			removed++
+			continue
+		}
+		constraints = append(constraints, constr)
+	}
+	sudok.Constraints = constraints
+	return sudok
+}
+
+// unsolvableClassicSudoku returns the classic sudoku with a 2 at R1C3,
+// although R1C1 is already a 2.
+func unsolvableClassicSudoku(t require.TestingT) sudoku.Sudoku {
+	sudok, _ := createClassicSudoku(t)
+	sudok.Constraints = append(sudok.Constraints,
+		constraint.FixedValueConstraint{Coordinate: sudoku.Coordinate{Row: 1, Col: 3}, Value: 2})
+	return sudok
+}
+
 func testSolveClassicSudoku(t *testing.T, mode backtrack.Mode) {
 	sudok, solution := createClassicSudoku(t)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -86,7 +123,7 @@ func testSolveClassicSudoku(t *testing.T, mode backtrack.Mode) {
 }
 
 func TestSolveClassicSudoku(t *testing.T) {
-	for _, mode := range modesToTest() {
+	for _, mode := range exactCoverModesToTest() {
 		t.Run(mode.String(), func(t *testing.T) {
 			testSolveClassicSudoku(t, mode)
 		})
@@ -106,7 +143,7 @@ func benchmarkSolveClassicSudoku(b *testing.B, mode backtrack.Mode) {
 }
 
 func BenchmarkSolveClassicSudoku(b *testing.B) {
-	for _, mode := range modesToTest() {
+	for _, mode := range exactCoverModesToTest() {
 		benchmarkSolveClassicSudoku(b, mode)
 	}
 }
@@ -187,6 +224,22 @@ func TestSolveArrowSudoku(t *testing.T) {
 	}
 }
 
+func TestParseModeRejectsArrowSudokuForDLX(t *testing.T) {
+	sudok, _ := createArrowSudoku(t)
+	_, err := backtrack.ParseMode("dlx", sudok)
+	assert.Error(t, err)
+	_, err = backtrack.ParseMode("pencilmark", sudok)
+	assert.NoError(t, err)
+}
+
+func TestFindSolutionsRejectsArrowSudokuForDLX(t *testing.T) {
+	sudok, _ := createArrowSudoku(t)
+	_, err := backtrack.FindSolutions(context.Background(), backtrack.ModeDLX, sudok)
+	assert.Error(t, err)
+	_, err = backtrack.FindSolutionsParallel(context.Background(), backtrack.ModeDLX, sudok, 4)
+	assert.Error(t, err)
+}
+
 func benchmarkSolveArrowSudoku(b *testing.B, mode backtrack.Mode) {
 	sudok, _ := createArrowSudoku(b)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -209,3 +262,477 @@ func BenchmarkSolveArrowSudoku(b *testing.B) {
 		benchmarkSolveArrowSudoku(b, mode)
 	}
 }
+
+func createChaosSudoku(t require.TestingT, size int, givens map[sudoku.Coordinate]int) sudoku.Sudoku {
+	sudok := sudoku.Sudoku{
+		RegionCount: size,
+	}
+	for value := 1; value <= size; value++ {
+		sudok.PossibleValues = append(sudok.PossibleValues, value)
+	}
+	for row := 1; row <= size; row++ {
+		for col := 1; col <= size; col++ {
+			sudok.Coordinates = append(sudok.Coordinates, sudoku.Coordinate{Row: row, Col: col})
+		}
+	}
+	rowConstraints, err := constraint.RowConstraints(sudok.Coordinates)
+	require.NoError(t, err)
+	colConstraints, err := constraint.ColumnConstraints(sudok.Coordinates)
+	require.NoError(t, err)
+	for _, c := range append(rowConstraints, colConstraints...) {
+		sudok.Constraints = append(sudok.Constraints, c)
+	}
+	for coord, value := range givens {
+		sudok.Constraints = append(sudok.Constraints, constraint.FixedValueConstraint{Coordinate: coord, Value: value})
+	}
+	return sudok
+}
+
+func TestSolveChaosSudoku(t *testing.T) {
+	tests := map[string]struct {
+		size   int
+		givens map[sudoku.Coordinate]int
+	}{
+		"Empty4x4": {size: 4},
+		"Givens5x5": {size: 5, givens: map[sudoku.Coordinate]int{
+			{Row: 1, Col: 1}: 1,
+			{Row: 2, Col: 2}: 1,
+			{Row: 3, Col: 5}: 4,
+			{Row: 5, Col: 3}: 2,
+		}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sudok := createChaosSudoku(t, test.size, test.givens)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			solved, err := backtrack.FindSolution(ctx, backtrack.ModeChaos, sudok)
+			require.NoError(t, err)
+			require.NoError(t, sudok.Check(solved))
+		})
+	}
+}
+
+func TestChaosSudokuNeedsRegions(t *testing.T) {
+	sudok := createChaosSudoku(t, 4, nil)
+	const solutionStr = `
+		12 34
+		34 12
+		21 43
+		43 21`
+	solution := readSolutionStr(t, solutionStr)
+	assert.Error(t, sudok.Check(solution))
+	assert.False(t, sudok.IsSolved(solution))
+}
+
+func TestDLXRejectsChaosSudoku(t *testing.T) {
+	// DLX can't find the regions, so it must not pretend that rows and
+	// columns are enough
+	sudok := createChaosSudoku(t, 4, nil)
+	ctx := context.Background()
+	_, err := backtrack.FindSolutions(ctx, backtrack.ModeDLX, sudok)
+	assert.Error(t, err)
+	_, _, err = backtrack.CountSolutions(ctx, backtrack.ModeDLX, sudok, 1)
+	assert.Error(t, err)
+	_, err = backtrack.CheckUniqueness(ctx, backtrack.ModeDLX, sudok)
+	assert.Error(t, err)
+	_, err = backtrack.FindBackbone(ctx, backtrack.ModeDLX, sudok)
+	assert.Error(t, err)
+}
+
+func TestSolveCardinalitySudoku(t *testing.T) {
+	// the rows of the classic sudoku are replaced by cardinality constraints
+	// that require each value exactly once, so the solution stays the same
+	sudok, solution := createClassicSudoku(t)
+	for i, constr := range sudok.Constraints {
+		nrc, ok := constr.(constraint.NoRepeatConstraint)
+		if !ok || nrc.Coordinates[0].Row != nrc.Coordinates[len(nrc.Coordinates)-1].Row {
+			continue
+		}
+		cardinality, err := constraint.NewEachValueCardinalityConstraint(nrc.Coordinates, sudok.PossibleValues, 1)
+		require.NoError(t, err)
+		sudok.Constraints[i] = *cardinality
+	}
+	for _, mode := range modesToTest() {
+		t.Run(mode.String(), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			solved, err := backtrack.FindSolution(ctx, mode, sudok)
+			require.NoError(t, err)
+			for _, coord := range sudok.Coordinates {
+				solutionValue, _ := solution.Get(coord)
+				solvedValue, ok := solved.Get(coord)
+				require.True(t, ok)
+				assert.Equal(t, solutionValue, solvedValue)
+			}
+		})
+	}
+}
+
+func TestFindAllSolutionsDLX(t *testing.T) {
+	// removing givens from the classic sudoku can only add solutions, and all
+	// modes have to agree on them
+	sudok := ambiguousClassicSudoku(t)
+
+	countSolutions := func(mode backtrack.Mode) int {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		solutions, err := backtrack.FindSolutions(ctx, mode, sudok)
+		require.NoError(t, err)
+		count := 0
+		for solution := range solutions {
+			require.NoError(t, sudok.Check(solution))
+			count++
+		}
+		return count
+	}
+	expected := countSolutions(backtrack.ModePencilMark)
+	assert.Greater(t, expected, 1)
+	assert.Equal(t, expected, countSolutions(backtrack.ModeDLX))
+}
+
+func TestSolveWithEmptyLastCoordinate(t *testing.T) {
+	sudok, _ := createClassicSudoku(t)
+	last := sudok.Coordinates[len(sudok.Coordinates)-1]
+	constraints := make([]sudoku.Constraint, 0, len(sudok.Constraints))
+	for _, constr := range sudok.Constraints {
+		if fvc, ok := constr.(constraint.FixedValueConstraint); ok && fvc.Coordinate == last {
+			continue
+		}
+		constraints = append(constraints, constr)
+	}
+	require.Len(t, constraints, len(sudok.Constraints)-1)
+	sudok.Constraints = constraints
+
+	for _, mode := range exactCoverModesToTest() {
+		t.Run(mode.String(), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			solved, err := backtrack.FindSolution(ctx, mode, sudok)
+			require.NoError(t, err)
+			require.NoError(t, sudok.Check(solved))
+		})
+	}
+}
+
+func TestFindSolutionsParallel(t *testing.T) {
+	// the classic sudoku without some givens has several hundred solutions,
+	// the parallel search has to find the same ones as the sequential search
+	sudok := ambiguousClassicSudoku(t)
+
+	collect := func(solutions <-chan sudoku.Solution, err error) map[string]struct{} {
+		require.NoError(t, err)
+		found := make(map[string]struct{})
+		for solution := range solutions {
+			require.NoError(t, sudok.Check(solution))
+			key := ""
+			for _, coord := range sudok.Coordinates {
+				value, _ := solution.Get(coord)
+				key += string(rune('0' + value))
+			}
+			found[key] = struct{}{}
+		}
+		return found
+	}
+	for _, mode := range []backtrack.Mode{backtrack.ModePencilMark, backtrack.ModePencilMarkMRV} {
+		t.Run(mode.String(), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			expected := collect(backtrack.FindSolutions(ctx, mode, sudok))
+			actual := collect(backtrack.FindSolutionsParallel(ctx, mode, sudok, 4))
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestFindSolutionsParallelCancel(t *testing.T) {
+	sudok := readSudokuStr(t, `
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---`)
+	ctx, cancel := context.WithCancel(context.Background())
+	solutions, err := backtrack.FindSolutionsParallel(ctx, backtrack.ModePencilMark, sudok, 4)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, more := <-solutions
+		require.True(t, more)
+	}
+	cancel()
+	for range solutions {
+		// drain until the workers stopped
+	}
+}
+
+func TestCountSolutions(t *testing.T) {
+	sudok, _ := createClassicSudoku(t)
+	for _, mode := range exactCoverModesToTest() {
+		t.Run(mode.String(), func(t *testing.T) {
+			count, limitHit, err := backtrack.CountSolutions(context.Background(), mode, sudok, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+			assert.False(t, limitHit)
+
+			// the limit is not hit if there are exactly as many solutions
+			count, limitHit, err = backtrack.CountSolutions(context.Background(), mode, sudok, 2)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+			assert.False(t, limitHit)
+		})
+	}
+}
+
+func TestCountSolutionsMatchesFindSolutions(t *testing.T) {
+	sudok := ambiguousClassicSudoku(t)
+
+	solutions, err := backtrack.FindSolutions(context.Background(), backtrack.ModePencilMark, sudok)
+	require.NoError(t, err)
+	expected := 0
+	for range solutions {
+		expected++
+	}
+	require.Greater(t, expected, 1)
+	for _, mode := range exactCoverModesToTest() {
+		if mode == backtrack.ModeSimple {
+			// too slow for several hundred solutions
+			continue
+		}
+		t.Run(mode.String(), func(t *testing.T) {
+			count, limitHit, err := backtrack.CountSolutions(context.Background(), mode, sudok, 0)
+			require.NoError(t, err)
+			assert.Equal(t, expected, count)
+			assert.False(t, limitHit)
+		})
+	}
+}
+
+func TestCountSolutionsStopsAtLimit(t *testing.T) {
+	sudok := readSudokuStr(t, `
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---`)
+	for _, mode := range exactCoverModesToTest() {
+		t.Run(mode.String(), func(t *testing.T) {
+			count, limitHit, err := backtrack.CountSolutions(context.Background(), mode, sudok, 5)
+			require.NoError(t, err)
+			assert.Equal(t, 5, count)
+			assert.True(t, limitHit)
+		})
+	}
+}
+
+func TestCountSolutionsWithContradictingGivens(t *testing.T) {
+	sudok := unsolvableClassicSudoku(t)
+	for _, mode := range exactCoverModesToTest() {
+		t.Run(mode.String(), func(t *testing.T) {
+			count, limitHit, err := backtrack.CountSolutions(context.Background(), mode, sudok, 0)
+			require.NoError(t, err)
+			assert.Zero(t, count)
+			assert.False(t, limitHit)
+		})
+	}
+}
+
+func TestCountSolutionsCancel(t *testing.T) {
+	sudok := readSudokuStr(t, `
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, limitHit, err := backtrack.CountSolutions(ctx, backtrack.ModePencilMark, sudok, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, limitHit)
+}
+
+func TestCheckUniqueness(t *testing.T) {
+	unique, _ := createClassicSudoku(t)
+
+	ambiguous := ambiguousClassicSudoku(t)
+
+	unsolvable := unsolvableClassicSudoku(t)
+
+	for _, mode := range exactCoverModesToTest() {
+		t.Run(mode.String(), func(t *testing.T) {
+			uniqueness, err := backtrack.CheckUniqueness(context.Background(), mode, unique)
+			require.NoError(t, err)
+			assert.True(t, uniqueness.IsUnique())
+			require.NoError(t, unique.Check(uniqueness.Solutions[0]))
+			assert.Empty(t, uniqueness.Differences)
+
+			uniqueness, err = backtrack.CheckUniqueness(context.Background(), mode, ambiguous)
+			require.NoError(t, err)
+			assert.True(t, uniqueness.HasSolution())
+			assert.False(t, uniqueness.IsUnique())
+			require.Len(t, uniqueness.Solutions, 2)
+			require.NotEmpty(t, uniqueness.Differences)
+			for _, solution := range uniqueness.Solutions {
+				require.NoError(t, ambiguous.Check(solution))
+			}
+			for _, coord := range ambiguous.Coordinates {
+				value1, _ := uniqueness.Solutions[0].Get(coord)
+				value2, _ := uniqueness.Solutions[1].Get(coord)
+				assert.Equal(t, value1 != value2, contains(uniqueness.Differences, coord), coord)
+			}
+
+			uniqueness, err = backtrack.CheckUniqueness(context.Background(), mode, unsolvable)
+			require.NoError(t, err)
+			assert.False(t, uniqueness.HasSolution())
+			assert.Empty(t, uniqueness.Differences)
+		})
+	}
+}
+
+func contains(coordinates []sudoku.Coordinate, coord sudoku.Coordinate) bool {
+	for _, c := range coordinates {
+		if c == coord {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFindBackbone(t *testing.T) {
+	unique, solution := createClassicSudoku(t)
+	unsolvable := unsolvableClassicSudoku(t)
+
+	for _, mode := range exactCoverModesToTest() {
+		if mode == backtrack.ModeSimple {
+			// too slow to refute every value
+			continue
+		}
+		t.Run(mode.String(), func(t *testing.T) {
+			backbone, err := backtrack.FindBackbone(context.Background(), mode, unique)
+			require.NoError(t, err)
+			for _, coord := range unique.Coordinates {
+				value, ok := backbone.Fixed(coord)
+				require.True(t, ok, coord)
+				expectedValue, _ := solution.Get(coord)
+				assert.Equal(t, expectedValue, value, coord)
+			}
+
+			backbone, err = backtrack.FindBackbone(context.Background(), mode, unsolvable)
+			require.NoError(t, err)
+			for _, coord := range unsolvable.Coordinates {
+				assert.Empty(t, backbone.Candidates[coord])
+			}
+		})
+	}
+}
+
+func TestFindBackboneOfAmbiguousSudoku(t *testing.T) {
+	sudok := ambiguousClassicSudoku(t)
+
+	// the true candidates are the values of all solutions
+	expected := make(map[sudoku.Coordinate]map[int]bool, len(sudok.Coordinates))
+	for _, coord := range sudok.Coordinates {
+		expected[coord] = make(map[int]bool)
+	}
+	solutions, err := backtrack.FindSolutions(context.Background(), backtrack.ModePencilMark, sudok)
+	require.NoError(t, err)
+	for solution := range solutions {
+		for _, coord := range sudok.Coordinates {
+			value, _ := solution.Get(coord)
+			expected[coord][value] = true
+		}
+	}
+
+	// refuting a value takes a full search, which is too slow for the modes
+	// that propagate less
+	for _, mode := range []backtrack.Mode{backtrack.ModePencilMarkMRV, backtrack.ModeTrail, backtrack.ModeNogood, backtrack.ModeDLX} {
+		t.Run(mode.String(), func(t *testing.T) {
+			backbone, err := backtrack.FindBackbone(context.Background(), mode, sudok)
+			require.NoError(t, err)
+			fixed := 0
+			for _, coord := range sudok.Coordinates {
+				assert.Len(t, backbone.Candidates[coord], len(expected[coord]), coord)
+				for _, value := range backbone.Candidates[coord] {
+					assert.True(t, expected[coord][value], coord)
+				}
+				if _, ok := backbone.Fixed(coord); ok {
+					fixed++
+				}
+			}
+			assert.Greater(t, fixed, 0)
+			assert.Less(t, fixed, len(sudok.Coordinates))
+		})
+	}
+}
+
+func TestMeasureSearch(t *testing.T) {
+	sudok, _ := createClassicSudoku(t)
+
+	// the deductions in the houses solve the sudoku without guessing
+	stats, err := backtrack.MeasureSearch(context.Background(), backtrack.ModePencilMarkMRV, sudok)
+	require.NoError(t, err)
+	assert.Equal(t, backtrack.SearchStats{Candidates: 1, Guesses: 0, Solutions: 1}, stats)
+
+	stats, err = backtrack.MeasureSearch(context.Background(), backtrack.ModeSimple, sudok)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Solutions)
+	assert.Greater(t, stats.Guesses, 0)
+	assert.Greater(t, stats.Candidates, stats.Guesses)
+
+	_, err = backtrack.MeasureSearch(context.Background(), backtrack.ModeDLX, sudok)
+	assert.Error(t, err)
+}
+
+// thrashingSudoku returns a row of four independent pairs of cells, which the
+// search branches on first because each of them can only have two values,
+// followed by the given number of cells that must all differ.
+func thrashingSudoku(different int) sudoku.Sudoku {
+	sudok := sudoku.Sudoku{PossibleValues: []int{1, 2, 3}}
+	for col := 1; col <= 8+different; col++ {
+		sudok.Coordinates = append(sudok.Coordinates, sudoku.Coordinate{Row: 1, Col: col})
+	}
+	for col := 1; col <= 8; col += 2 {
+		sudok.Constraints = append(sudok.Constraints, constraint.LessThanConstraint{
+			Smaller: sudoku.Coordinate{Row: 1, Col: col},
+			Bigger:  sudoku.Coordinate{Row: 1, Col: col + 1},
+		})
+	}
+	sudok.Constraints = append(sudok.Constraints, constraint.NoRepeatConstraint{Coordinates: sudok.Coordinates[8:]})
+	return sudok
+}
+
+func TestMeasureSearchWithNogoods(t *testing.T) {
+	// four cells can't have three different values, which the search finds
+	// out again for every value of the pairs unless it learns that the pairs
+	// don't matter
+	unsolvable := thrashingSudoku(4)
+	stats, err := backtrack.MeasureSearch(context.Background(), backtrack.ModePencilMarkMRV, unsolvable)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Solutions)
+	learned, err := backtrack.MeasureSearch(context.Background(), backtrack.ModeNogood, unsolvable)
+	require.NoError(t, err)
+	assert.Zero(t, learned.Solutions)
+	assert.Less(t, learned.Candidates*10, stats.Candidates)
+
+	// a store of one nogood still prunes correctly
+	limited, err := backtrack.MeasureSearch(context.Background(), backtrack.NogoodMode(1), unsolvable)
+	require.NoError(t, err)
+	assert.Zero(t, limited.Solutions)
+
+	// every pair has three and the three different cells six solutions
+	solvable := thrashingSudoku(3)
+	learned, err = backtrack.MeasureSearch(context.Background(), backtrack.ModeNogood, solvable)
+	require.NoError(t, err)
+	assert.Equal(t, 3*3*3*3*6, learned.Solutions)
+}
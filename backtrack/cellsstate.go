@@ -1,39 +1,91 @@
 package backtrack
 
 import (
-	"slices"
+	"fmt"
+	"math"
+	"math/bits"
 	"sudoku-solver/sudoku"
 )
 
-type cellState struct {
-	HasValue      bool
-	Value         int
-	Possibilities []int
+// valueSet is a set of values stored as a bitmask where bit v is set if the
+// value v is in the set. It can only hold the values 0 to maxSetValue, other
+// values are ignored.
+type valueSet uint64
+
+const maxSetValue = 63
+
+func newValueSet(values ...int) valueSet {
+	var set valueSet
+	for _, value := range values {
+		if value < 0 || value > maxSetValue {
+			continue
+		}
+		set |= 1 << uint(value)
+	}
+	return set
+}
+
+func (s valueSet) Contains(value int) bool {
+	if value < 0 || value > maxSetValue {
+		return false
+	}
+	return s&(1<<uint(value)) != 0
 }
 
-type cellsState map[sudoku.Coordinate]cellState
+func (s valueSet) Len() int {
+	return bits.OnesCount64(uint64(s))
+}
 
-var _ sudoku.Solution = cellsState(nil)
+// Min returns the smallest value in the set. The set must not be empty.
+func (s valueSet) Min() int {
+	return bits.TrailingZeros64(uint64(s))
+}
 
-func (s cellState) Copy() cellState {
-	newPossibilities := make([]int, len(s.Possibilities))
-	copy(newPossibilities, s.Possibilities)
-	return cellState{
-		HasValue:      s.HasValue,
-		Value:         s.Value,
-		Possibilities: newPossibilities,
+// Max returns the biggest value in the set. The set must not be empty.
+func (s valueSet) Max() int {
+	return 63 - bits.LeadingZeros64(uint64(s))
+}
+
+// Values returns the values in the set in ascending order.
+func (s valueSet) Values() []int {
+	values := make([]int, 0, s.Len())
+	for rest := s; rest != 0; rest &= rest - 1 {
+		values = append(values, bits.TrailingZeros64(uint64(rest)))
 	}
+	return values
+}
+
+// Filter returns the set of values for which f returns true.
+func (s valueSet) Filter(f func(int) bool) valueSet {
+	var filtered valueSet
+	for rest := s; rest != 0; rest &= rest - 1 {
+		value := bits.TrailingZeros64(uint64(rest))
+		if f(value) {
+			filtered |= 1 << uint(value)
+		}
+	}
+	return filtered
+}
+
+type cellState struct {
+	HasValue      bool
+	Value         int
+	Possibilities valueSet
 }
 
 func FixedCellState(value int) cellState {
 	return cellState{
 		HasValue:      true,
 		Value:         value,
-		Possibilities: nil,
+		Possibilities: 0,
 	}
 }
 
 func VariableCellState(possibilities []int) cellState {
+	return variableCellState(newValueSet(possibilities...))
+}
+
+func variableCellState(possibilities valueSet) cellState {
 	return cellState{
 		HasValue:      false,
 		Value:         0,
@@ -51,10 +103,8 @@ func (s cellState) WithRemovedPossibilities(values ...int) (cellState, bool) {
 	if s.HasValue {
 		return s, true
 	}
-	newPossibilities := Filter(s.Possibilities, func(value int) bool {
-		return !slices.Contains(values, value)
-	})
-	return VariableCellState(newPossibilities), len(newPossibilities) > 0
+	newPossibilities := s.Possibilities &^ newValueSet(values...)
+	return variableCellState(newPossibilities), newPossibilities != 0
 }
 
 // WithConstrainedPossibilities returns a new cellState that only allows the given values.
@@ -63,20 +113,149 @@ func (s cellState) WithRemovedPossibilities(values ...int) (cellState, bool) {
 // If the cell has no value, this method returns a new cellState that only allows the
 // given values and returns true if the new cellState has at least one possibility left.
 func (s cellState) WithConstrainedPossibilities(values ...int) (cellState, bool) {
+	return s.withConstrainedSet(newValueSet(values...))
+}
+
+func (s cellState) withConstrainedSet(values valueSet) (cellState, bool) {
+	if s.HasValue {
+		return s, values.Contains(s.Value)
+	}
+	newPossibilities := s.Possibilities & values
+	return variableCellState(newPossibilities), newPossibilities != 0
+}
+
+// Bounds returns the smallest and the biggest value this cell can still have.
+func (s cellState) Bounds() (int, int) {
 	if s.HasValue {
-		return s, slices.Contains(values, s.Value)
+		return s.Value, s.Value
+	}
+	if s.Possibilities == 0 {
+		return 0, 0
 	}
-	newPossibilities := make([]int, 0, len(s.Possibilities))
-	for _, value := range s.Possibilities {
-		if slices.Contains(values, value) {
-			newPossibilities = append(newPossibilities, value)
+	return s.Possibilities.Min(), s.Possibilities.Max()
+}
+
+// cellGrid maps the coordinates of a sudoku to dense indices. The index of a
+// coordinate is looked up in a table that spans the bounding box of all
+// coordinates. A grid is shared by all states of a search.
+type cellGrid struct {
+	coordinates    []sudoku.Coordinate
+	minRow, minCol int
+	width, height  int
+	// indices holds the index of every coordinate in the bounding box or -1
+	// if the coordinate is not part of the sudoku
+	indices []int
+}
+
+func newCellGrid(sudok sudoku.Sudoku) (*cellGrid, error) {
+	for _, value := range sudok.PossibleValues {
+		if value < 0 || value > maxSetValue {
+			return nil, fmt.Errorf("value %d is not between 0 and %d", value, maxSetValue)
 		}
 	}
-	return VariableCellState(newPossibilities), len(newPossibilities) > 0
+	g := &cellGrid{
+		coordinates: sudok.Coordinates,
+	}
+	if len(sudok.Coordinates) == 0 {
+		return g, nil
+	}
+	minRow, maxRow := math.MaxInt, math.MinInt
+	minCol, maxCol := math.MaxInt, math.MinInt
+	for _, coord := range sudok.Coordinates {
+		minRow, maxRow = min(minRow, coord.Row), max(maxRow, coord.Row)
+		minCol, maxCol = min(minCol, coord.Col), max(maxCol, coord.Col)
+	}
+	g.minRow, g.minCol = minRow, minCol
+	g.height, g.width = maxRow-minRow+1, maxCol-minCol+1
+	g.indices = make([]int, g.width*g.height)
+	for i := range g.indices {
+		g.indices[i] = -1
+	}
+	for i, coord := range sudok.Coordinates {
+		g.indices[(coord.Row-minRow)*g.width+coord.Col-minCol] = i
+	}
+	return g, nil
+}
+
+// index returns the index of the coordinate and false if it is not part of
+// the grid.
+func (g *cellGrid) index(coordinate sudoku.Coordinate) (int, bool) {
+	row, col := coordinate.Row-g.minRow, coordinate.Col-g.minCol
+	if row < 0 || row >= g.height || col < 0 || col >= g.width {
+		return 0, false
+	}
+	i := g.indices[row*g.width+col]
+	return i, i >= 0
+}
+
+// cellsState holds the state of every cell of a grid. Copying it only copies
+// one slice. If it has a trail, every change is recorded on the trail so that
+// it can be undone later.
+type cellsState struct {
+	grid  *cellGrid
+	cells []cellState
+	trail *[]cellChange
+}
+
+// cellChange is an entry on a trail. It holds the state a cell had before it
+// was changed.
+type cellChange struct {
+	index    int
+	previous cellState
+}
+
+var _ sudoku.Solution = cellsState{}
+
+// newCellsState creates a state where every cell can have all given values.
+func newCellsState(grid *cellGrid, possibleValues []int) cellsState {
+	cells := make([]cellState, len(grid.coordinates))
+	for i := range cells {
+		cells[i] = VariableCellState(possibleValues)
+	}
+	return cellsState{grid: grid, cells: cells}
+}
+
+// Lookup returns the state of the cell at the coordinate and false if the
+// coordinate is not part of the grid.
+func (s cellsState) Lookup(coordinate sudoku.Coordinate) (cellState, bool) {
+	i, ok := s.grid.index(coordinate)
+	if !ok {
+		return cellState{}, false
+	}
+	return s.cells[i], true
+}
+
+// At returns the state of the cell at the coordinate or an empty state if the
+// coordinate is not part of the grid.
+func (s cellsState) At(coordinate sudoku.Coordinate) cellState {
+	state, _ := s.Lookup(coordinate)
+	return state
+}
+
+// Set changes the state of the cell at the coordinate. Coordinates that are
+// not part of the grid are ignored.
+func (s cellsState) Set(coordinate sudoku.Coordinate, state cellState) {
+	i, ok := s.grid.index(coordinate)
+	if !ok {
+		return
+	}
+	if s.trail != nil && s.cells[i] != state {
+		*s.trail = append(*s.trail, cellChange{index: i, previous: s.cells[i]})
+	}
+	s.cells[i] = state
+}
+
+// undo reverts all changes on the trail after the given length.
+func (s cellsState) undo(length int) {
+	trail := *s.trail
+	for i := len(trail) - 1; i >= length; i-- {
+		s.cells[trail[i].index] = trail[i].previous
+	}
+	*s.trail = trail[:length]
 }
 
 func (s cellsState) Get(coordinate sudoku.Coordinate) (int, bool) {
-	coorState, ok := s[coordinate]
+	coorState, ok := s.Lookup(coordinate)
 	if !ok {
 		return 0, false
 	}
@@ -86,10 +265,9 @@ func (s cellsState) Get(coordinate sudoku.Coordinate) (int, bool) {
 	return coorState.Value, true
 }
 
+// Copy returns a copy of the state without a trail.
 func (s cellsState) Copy() cellsState {
-	newState := make(cellsState, len(s))
-	for coor, coorState := range s {
-		newState[coor] = coorState.Copy()
-	}
-	return newState
+	cells := make([]cellState, len(s.cells))
+	copy(cells, s.cells)
+	return cellsState{grid: s.grid, cells: cells}
 }
@@ -1,6 +1,8 @@
 package backtrack
 
 import (
+	"slices"
+	"sudoku-solver/sudoku"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,11 +12,11 @@ import (
 func TestRemovePossibilities(t *testing.T) {
 	t.Run("PossibilitesCantGetBigger", func(t *testing.T) {
 		rapid.Check(t, func(t *rapid.T) {
-			possibilites := rapid.SliceOf(rapid.Int()).Draw(t, "possibilities")
+			possibilites := rapid.SliceOf(rapid.IntRange(0, maxSetValue)).Draw(t, "possibilities")
 			ori := VariableCellState(possibilites)
 			removed := rapid.SliceOf(rapid.Int()).Draw(t, "removed")
 			updated, _ := ori.WithRemovedPossibilities(removed...)
-			assert.True(t, len(updated.Possibilities) <= len(ori.Possibilities))
+			assert.True(t, updated.Possibilities.Len() <= ori.Possibilities.Len())
 		})
 	})
 
@@ -30,10 +32,61 @@ func TestRemovePossibilities(t *testing.T) {
 
 	t.Run("RemoveTheLastValueReturnsFalse", func(t *testing.T) {
 		rapid.Check(t, func(t *rapid.T) {
-			value := rapid.Int().Draw(t, "value")
+			value := rapid.IntRange(0, maxSetValue).Draw(t, "value")
 			cs := VariableCellState([]int{value})
 			_, ok := cs.WithRemovedPossibilities(value)
 			assert.False(t, ok)
 		})
 	})
 }
+
+func TestValueSet(t *testing.T) {
+	t.Run("ValuesAreSortedAndUnique", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			values := rapid.SliceOf(rapid.IntRange(0, maxSetValue)).Draw(t, "values")
+			expected := slices.Clone(values)
+			slices.Sort(expected)
+			expected = slices.Compact(expected)
+			set := newValueSet(values...)
+			assert.Equal(t, len(expected), set.Len())
+			assert.Equal(t, expected, set.Values())
+		})
+	})
+
+	t.Run("BoundsMatchValues", func(t *testing.T) {
+		rapid.Check(t, func(t *rapid.T) {
+			values := rapid.SliceOfN(rapid.IntRange(0, maxSetValue), 1, -1).Draw(t, "values")
+			set := newValueSet(values...)
+			assert.Equal(t, slices.Min(values), set.Min())
+			assert.Equal(t, slices.Max(values), set.Max())
+		})
+	})
+}
+
+func TestTrailUndo(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		sudok := sudoku.Sudoku{PossibleValues: []int{1, 2, 3, 4}}
+		for row := 0; row < 4; row++ {
+			for col := 0; col < 4; col++ {
+				sudok.Coordinates = append(sudok.Coordinates, sudoku.Coordinate{Row: row, Col: col})
+			}
+		}
+		grid, err := newCellGrid(sudok)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := newCellsState(grid, sudok.PossibleValues)
+		state.trail = &[]cellChange{}
+		before := state.Copy()
+
+		changes := rapid.IntRange(0, 20).Draw(t, "changes")
+		for i := 0; i < changes; i++ {
+			coord := rapid.SampledFrom(sudok.Coordinates).Draw(t, "coordinate")
+			value := rapid.SampledFrom(sudok.PossibleValues).Draw(t, "value")
+			state.Set(coord, FixedCellState(value))
+		}
+		state.undo(0)
+		assert.Equal(t, before.cells, state.cells)
+		assert.Empty(t, *state.trail)
+	})
+}
@@ -0,0 +1,149 @@
+package backtrack
+
+import (
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+// chaosCandidate searches the values and the unknown regions of a chaos
+// construction sudoku at the same time. The values are tracked like in the
+// pencilmark mode. Coordinates are visited in order and every visited
+// coordinate is assigned a value and a region in one step.
+type chaosCandidate struct {
+	pencilmarkCandidate
+	regions map[sudoku.Coordinate]int
+
+	// usedRegions is the number of regions that already have a cell. To avoid
+	// finding the same division with different region numbers, a coordinate
+	// can only join one of these regions or start region number usedRegions.
+	usedRegions int
+}
+
+var _ Candidate = (*chaosCandidate)(nil)
+var _ sudoku.RegionSolution = (*chaosCandidate)(nil)
+
+func rootChaos(sudok sudoku.Sudoku) (Candidate, error) {
+	if sudok.RegionCount <= 0 {
+		return nil, fmt.Errorf("sudoku has no unknown regions")
+	}
+	if len(sudok.Coordinates)%sudok.RegionCount != 0 {
+		return nil, fmt.Errorf("%d coordinates can't be divided into %d regions", len(sudok.Coordinates), sudok.RegionCount)
+	}
+	root, err := rootPencilMark(sudok, selectInOrder, propagateConstraints)
+	if err != nil {
+		return nil, err
+	}
+	return &chaosCandidate{
+		pencilmarkCandidate: *root.(*pencilmarkCandidate),
+		regions:             make(map[sudoku.Coordinate]int, len(sudok.Coordinates)),
+		usedRegions:         0,
+	}, nil
+}
+
+func (c *chaosCandidate) Region(coordinate sudoku.Coordinate) (int, bool) {
+	region, ok := c.regions[coordinate]
+	return region, ok
+}
+
+func (c *chaosCandidate) copy() *chaosCandidate {
+	regions := make(map[sudoku.Coordinate]int, len(c.sudok.Coordinates))
+	for coord, region := range c.regions {
+		regions[coord] = region
+	}
+	return &chaosCandidate{
+		pencilmarkCandidate: *c.pencilmarkCandidate.child(),
+		regions:             regions,
+		usedRegions:         c.usedRegions,
+	}
+}
+
+func (c *chaosCandidate) NextCandidates() []Candidate {
+	if c.coordinateIndex >= len(c.sudok.Coordinates) {
+		return nil
+	}
+	coord := c.sudok.Coordinates[c.coordinateIndex]
+	cell, ok := c.cellsState.Lookup(coord)
+	if !ok {
+		// this should never happen
+		panic(fmt.Sprintf("coordinate %v not found in state", coord))
+	}
+	values := cell.Possibilities.Values()
+	if cell.HasValue {
+		values = []int{cell.Value}
+	}
+
+	regionOptions := c.usedRegions
+	if c.usedRegions < c.sudok.RegionCount {
+		regionOptions++
+	}
+	nextCandidates := make([]Candidate, 0, regionOptions*len(values))
+	for region := 0; region < regionOptions; region++ {
+		for _, value := range values {
+			newCandidate := c.copy()
+			newCandidate.regions[coord] = region
+			if region == c.usedRegions {
+				newCandidate.usedRegions++
+			}
+			if !cell.HasValue {
+				if err := newCandidate.FillIn(coord, value); err != nil {
+					// filling in this value makes the sudoku unsolvable
+					continue
+				}
+			}
+			if !newCandidate.regionsCanBeCompleted() {
+				continue
+			}
+			nextCandidates = append(nextCandidates, newCandidate)
+		}
+	}
+	return nextCandidates
+}
+
+// regionsCanBeCompleted returns false if a started region can't grow into a
+// connected region of the right size anymore, using only the coordinates
+// that are not assigned to a region yet.
+func (c *chaosCandidate) regionsCanBeCompleted() bool {
+	size := c.sudok.RegionSize()
+	members := make(map[int][]sudoku.Coordinate, c.usedRegions)
+	for _, coord := range c.sudok.Coordinates {
+		if region, ok := c.regions[coord]; ok {
+			members[region] = append(members[region], coord)
+		}
+	}
+	for region, cells := range members {
+		if len(cells) > size {
+			return false
+		}
+		// walk through the region and all free coordinates starting at any
+		// cell of the region
+		reached := map[sudoku.Coordinate]bool{cells[0]: true}
+		queue := []sudoku.Coordinate{cells[0]}
+		reachedMembers := 0
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if currentRegion, ok := c.regions[current]; ok && currentRegion == region {
+				reachedMembers++
+			}
+			for _, neighbour := range current.OrthogonalNeighbours() {
+				if reached[neighbour] {
+					continue
+				}
+				if _, ok := c.cellsState.Lookup(neighbour); !ok {
+					// not part of the sudoku
+					continue
+				}
+				neighbourRegion, assigned := c.regions[neighbour]
+				if assigned && neighbourRegion != region {
+					continue
+				}
+				reached[neighbour] = true
+				queue = append(queue, neighbour)
+			}
+		}
+		if len(reached) < size || reachedMembers < len(cells) {
+			return false
+		}
+	}
+	return true
+}
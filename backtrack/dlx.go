@@ -0,0 +1,255 @@
+package backtrack
+
+import (
+	"context"
+	"fmt"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+)
+
+// dlxMatrix is an exact cover matrix stored as dancing links (Knuth's
+// Algorithm X). Every row of the matrix places one value at one coordinate.
+// The columns are:
+//   - one primary column per coordinate, so every coordinate gets exactly one
+//     value
+//   - one column per house and value. Houses with as many cells as there are
+//     possible values need every value exactly once, so their columns are
+//     primary. Smaller houses only forbid repeats, so their columns are
+//     secondary and may stay uncovered.
+//
+// All nodes live in slices and link to each other by index. Index 0 is the
+// root, the indices 1 to columnCount are the column headers.
+type dlxMatrix struct {
+	left, right, up, down []int
+	// column is the column header of every node
+	column []int
+	// row is the matrix row of every node, -1 for headers
+	row []int
+	// size is the number of nodes in every column, indexed by header
+	size []int
+
+	placements []dlxPlacement
+}
+
+type dlxPlacement struct {
+	coordinate sudoku.Coordinate
+	value      int
+}
+
+// canUseDLX returns an error if the sudoku has constraints that can't be
+// encoded as an exact cover problem.
+func canUseDLX(sudok sudoku.Sudoku) error {
+	for _, constr := range sudok.Constraints {
+		switch constr.(type) {
+		case constraint.NoRepeatConstraint, *constraint.NoRepeatConstraint:
+		case constraint.FixedValueConstraint, *constraint.FixedValueConstraint:
+		default:
+			return fmt.Errorf("constraint %T can't be encoded as exact cover, only no repeat and fixed value constraints can", constr)
+		}
+	}
+	return nil
+}
+
+func newDLXMatrix(sudok sudoku.Sudoku) (*dlxMatrix, error) {
+	if err := canUseDLX(sudok); err != nil {
+		return nil, err
+	}
+	coordinateIndex := make(map[sudoku.Coordinate]int, len(sudok.Coordinates))
+	for i, coord := range sudok.Coordinates {
+		coordinateIndex[coord] = i
+	}
+	valueIndex := make(map[int]int, len(sudok.PossibleValues))
+	for i, value := range sudok.PossibleValues {
+		valueIndex[value] = i
+	}
+
+	fixed := make(map[sudoku.Coordinate]int)
+	houses := make([][]sudoku.Coordinate, 0)
+	for _, constr := range sudok.Constraints {
+		switch constr := constr.(type) {
+		case constraint.NoRepeatConstraint:
+			houses = append(houses, constr.Coordinates)
+		case *constraint.NoRepeatConstraint:
+			houses = append(houses, constr.Coordinates)
+		case constraint.FixedValueConstraint:
+			if err := addFixedValue(fixed, constr); err != nil {
+				return nil, err
+			}
+		case *constraint.FixedValueConstraint:
+			if err := addFixedValue(fixed, *constr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// primary columns have to come first, so that only they are linked into
+	// the header list of the root
+	cellColumns := len(sudok.Coordinates)
+	primaryHouses := make([]int, 0, len(houses))
+	secondaryHouses := make([]int, 0, len(houses))
+	for i, house := range houses {
+		if len(house) == len(sudok.PossibleValues) {
+			primaryHouses = append(primaryHouses, i)
+		} else {
+			secondaryHouses = append(secondaryHouses, i)
+		}
+	}
+	houseColumnStart := make([]int, len(houses))
+	nextColumn := 1 + cellColumns
+	for _, i := range append(primaryHouses, secondaryHouses...) {
+		houseColumnStart[i] = nextColumn
+		nextColumn += len(sudok.PossibleValues)
+	}
+	columnCount := nextColumn - 1
+	primaryCount := cellColumns + len(primaryHouses)*len(sudok.PossibleValues)
+
+	m := &dlxMatrix{}
+	for header := 0; header <= columnCount; header++ {
+		m.left = append(m.left, header)
+		m.right = append(m.right, header)
+		m.up = append(m.up, header)
+		m.down = append(m.down, header)
+		m.column = append(m.column, header)
+		m.row = append(m.row, -1)
+		m.size = append(m.size, 0)
+	}
+	// link the primary headers into a circular list with the root
+	for header := 0; header <= primaryCount; header++ {
+		m.right[header] = (header + 1) % (primaryCount + 1)
+		m.left[(header+1)%(primaryCount+1)] = header
+	}
+
+	housesOf := make(map[sudoku.Coordinate][]int)
+	for i, house := range houses {
+		for _, coord := range house {
+			housesOf[coord] = append(housesOf[coord], i)
+		}
+	}
+	for _, coord := range sudok.Coordinates {
+		values := sudok.PossibleValues
+		if value, ok := fixed[coord]; ok {
+			if _, ok := valueIndex[value]; !ok {
+				return nil, fmt.Errorf("fixed value %d at %v is not a possible value: %w", value, coord, errNoSolution)
+			}
+			values = []int{value}
+		}
+		for _, value := range values {
+			columns := []int{1 + coordinateIndex[coord]}
+			for _, house := range housesOf[coord] {
+				columns = append(columns, houseColumnStart[house]+valueIndex[value])
+			}
+			m.addRow(dlxPlacement{coordinate: coord, value: value}, columns)
+		}
+	}
+	return m, nil
+}
+
+func addFixedValue(fixed map[sudoku.Coordinate]int, fvc constraint.FixedValueConstraint) error {
+	if value, ok := fixed[fvc.Coordinate]; ok && value != fvc.Value {
+		return fmt.Errorf("coordinate %v is fixed to %d and %d: %w", fvc.Coordinate, value, fvc.Value, errNoSolution)
+	}
+	fixed[fvc.Coordinate] = fvc.Value
+	return nil
+}
+
+func (m *dlxMatrix) addRow(placement dlxPlacement, columns []int) {
+	rowIndex := len(m.placements)
+	m.placements = append(m.placements, placement)
+	first := len(m.column)
+	for i, header := range columns {
+		node := len(m.column)
+		m.column = append(m.column, header)
+		m.row = append(m.row, rowIndex)
+		// insert at the bottom of the column
+		m.up = append(m.up, m.up[header])
+		m.down = append(m.down, header)
+		m.down[m.up[header]] = node
+		m.up[header] = node
+		m.size[header]++
+		// insert at the end of the row
+		if i == 0 {
+			m.left = append(m.left, node)
+			m.right = append(m.right, node)
+			continue
+		}
+		m.left = append(m.left, m.left[first])
+		m.right = append(m.right, first)
+		m.right[m.left[first]] = node
+		m.left[first] = node
+	}
+}
+
+func (m *dlxMatrix) cover(header int) {
+	m.right[m.left[header]] = m.right[header]
+	m.left[m.right[header]] = m.left[header]
+	for i := m.down[header]; i != header; i = m.down[i] {
+		for j := m.right[i]; j != i; j = m.right[j] {
+			m.down[m.up[j]] = m.down[j]
+			m.up[m.down[j]] = m.up[j]
+			m.size[m.column[j]]--
+		}
+	}
+}
+
+func (m *dlxMatrix) uncover(header int) {
+	for i := m.up[header]; i != header; i = m.up[i] {
+		for j := m.left[i]; j != i; j = m.left[j] {
+			m.size[m.column[j]]++
+			m.down[m.up[j]] = j
+			m.up[m.down[j]] = j
+		}
+	}
+	m.right[m.left[header]] = header
+	m.left[m.right[header]] = header
+}
+
+// searchCovers runs Algorithm X and calls found with the rows of every exact
+// cover. It stops as soon as found returns false and returns false in that
+// case.
+func (m *dlxMatrix) searchCovers(ctx context.Context, chosen []int, found func([]int) bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if m.right[0] == 0 {
+		return found(chosen)
+	}
+	// choose the primary column with the fewest rows
+	header := m.right[0]
+	for c := m.right[header]; c != 0; c = m.right[c] {
+		if m.size[c] < m.size[header] {
+			header = c
+		}
+	}
+	if m.size[header] == 0 {
+		return true
+	}
+	m.cover(header)
+	defer m.uncover(header)
+	for i := m.down[header]; i != header; i = m.down[i] {
+		for j := m.right[i]; j != i; j = m.right[j] {
+			m.cover(m.column[j])
+		}
+		more := m.searchCovers(ctx, append(chosen, m.row[i]), found)
+		for j := m.left[i]; j != i; j = m.left[j] {
+			m.uncover(m.column[j])
+		}
+		if !more {
+			return false
+		}
+	}
+	return true
+}
+
+var _ engine = (*dlxMatrix)(nil)
+
+// search calls found with a solution for every exact cover.
+func (m *dlxMatrix) search(ctx context.Context, found func(sudoku.Solution) bool) {
+	solution := make(map[sudoku.Coordinate]int)
+	m.searchCovers(ctx, make([]int, 0), func(rows []int) bool {
+		for _, row := range rows {
+			placement := m.placements[row]
+			solution[placement.coordinate] = placement.value
+		}
+		return found(sudoku.MapSolution(solution))
+	})
+}
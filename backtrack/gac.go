@@ -0,0 +1,121 @@
+package backtrack
+
+import (
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+// enforceArcConsistency makes every constraint generalized arc consistent:
+// every possibility of a cell can be extended to values for all other cells
+// of the constraint that satisfy it. The constraints of the given coordinates
+// are put on a work queue. Whenever a cell loses a possibility, the other
+// constraints of the cell are put on the queue again. Cells with a single
+// possibility left are filled in once the queue is empty.
+func enforceArcConsistency(c *pencilmarkCandidate, coordinates []sudoku.Coordinate) error {
+	queued := make([]bool, c.index.Len())
+	queue := make([]int, 0)
+	enqueue := func(coordinate sudoku.Coordinate, except int) {
+		for _, position := range c.index.Positions(coordinate) {
+			if position == except || queued[position] {
+				continue
+			}
+			queued[position] = true
+			queue = append(queue, position)
+		}
+	}
+	for _, coordinate := range coordinates {
+		enqueue(coordinate, -1)
+	}
+	for len(queue) > 0 {
+		position := queue[0]
+		queue = queue[1:]
+		queued[position] = false
+		constr := c.index.Constraint(position)
+		changed, err := c.revise(constr)
+		if err != nil {
+			return fmt.Errorf("revise %T: %w", constr, err)
+		}
+		for _, coordinate := range changed {
+			enqueue(coordinate, position)
+		}
+	}
+
+	for i, state := range c.cells {
+		if state.HasValue || state.Possibilities.Len() != 1 {
+			continue
+		}
+		coordinate := c.grid.coordinates[i]
+		c.cellsState.Set(coordinate, FixedCellState(state.Possibilities.Min()))
+		if c.filled != nil {
+			c.filled = append(c.filled, coordinate)
+		}
+	}
+	return nil
+}
+
+// revise removes the possibilities without support from the cells of the
+// constraint until all possibilities have support. Constraints that don't
+// implement sudoku.Supporter are propagated instead. It returns the
+// coordinates that lost possibilities.
+func (c *pencilmarkCandidate) revise(constr sudoku.Constraint) ([]sudoku.Coordinate, error) {
+	supporter, ok := constr.(sudoku.Supporter)
+	if !ok {
+		return c.propagateBounds(constr)
+	}
+	changed := make([]sudoku.Coordinate, 0)
+	for {
+		changedAny := false
+		for _, coordinate := range constr.ConstrainedCoordinates() {
+			state, ok := c.cellsState.Lookup(coordinate)
+			if !ok {
+				continue
+			}
+			allowed := newValueSet(supporter.Supported(c, coordinate)...)
+			if state.HasValue {
+				if !allowed.Contains(state.Value) {
+					return nil, fmt.Errorf("value %d at %v has no support", state.Value, coordinate)
+				}
+				continue
+			}
+			if state.Possibilities&allowed == state.Possibilities {
+				continue
+			}
+			updated, stillSolvable := state.withConstrainedSet(allowed)
+			if !stillSolvable {
+				return nil, fmt.Errorf("coordinate %v has no supported possibilities left", coordinate)
+			}
+			c.cellsState.Set(coordinate, updated)
+			changed = append(changed, coordinate)
+			changedAny = true
+		}
+		if !changedAny {
+			return changed, nil
+		}
+	}
+}
+
+// propagateBounds propagates a constraint that can't tell the supported values
+// of its cells. Searching them would grow exponentially with the number of
+// empty cells, so the constraint only removes what its own propagation
+// removes. It returns the coordinates that lost possibilities.
+func (c *pencilmarkCandidate) propagateBounds(constr sudoku.Constraint) ([]sudoku.Coordinate, error) {
+	propagator, ok := constr.(sudoku.Propagator)
+	if !ok {
+		return nil, nil
+	}
+	coordinates := constr.ConstrainedCoordinates()
+	before := make([]cellState, len(coordinates))
+	for i, coordinate := range coordinates {
+		before[i], _ = c.cellsState.Lookup(coordinate)
+	}
+	if err := propagator.Propagate(c); err != nil {
+		return nil, err
+	}
+	changed := make([]sudoku.Coordinate, 0)
+	for i, coordinate := range coordinates {
+		if state, _ := c.cellsState.Lookup(coordinate); state != before[i] {
+			changed = append(changed, coordinate)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,257 @@
+package backtrack
+
+import (
+	"fmt"
+	"slices"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+)
+
+// houses holds the groups of coordinates that contain every possible value
+// exactly once, i.e. no repeat constraints with as many coordinates as there
+// are possible values. They allow deductions that look at a whole group
+// instead of a single cell.
+type houses struct {
+	groups [][]sudoku.Coordinate
+	// intersections holds every pair of groups that share at least two
+	// coordinates, together with the shared coordinates.
+	intersections []houseIntersection
+}
+
+type houseIntersection struct {
+	first, second int
+	shared        []sudoku.Coordinate
+}
+
+func newHouses(sudok sudoku.Sudoku) *houses {
+	h := &houses{}
+	for _, constr := range sudok.Constraints {
+		var coordinates []sudoku.Coordinate
+		switch constr := constr.(type) {
+		case constraint.NoRepeatConstraint:
+			coordinates = constr.Coordinates
+		case *constraint.NoRepeatConstraint:
+			coordinates = constr.Coordinates
+		default:
+			continue
+		}
+		if len(coordinates) != len(sudok.PossibleValues) {
+			continue
+		}
+		h.groups = append(h.groups, coordinates)
+	}
+	for i := range h.groups {
+		for j := i + 1; j < len(h.groups); j++ {
+			shared := make([]sudoku.Coordinate, 0)
+			for _, coord := range h.groups[i] {
+				if slices.Contains(h.groups[j], coord) {
+					shared = append(shared, coord)
+				}
+			}
+			if len(shared) < 2 {
+				continue
+			}
+			h.intersections = append(h.intersections, houseIntersection{first: i, second: j, shared: shared})
+		}
+	}
+	return h
+}
+
+// deduceInHouses removes possibilities that the houses rule out until nothing
+// changes anymore:
+//   - hidden singles: a value that is only possible in one cell of a house
+//     has to go there
+//   - naked pairs and triples: if two (three) cells of a house can only have
+//     the same two (three) values, no other cell of the house can have them
+//   - pointing and claiming: if a value of a house is only possible in the
+//     cells it shares with another house, it can't be anywhere else in the
+//     other house
+//
+// It returns whether anything changed and an error if a house can no longer
+// be completed.
+func (c *pencilmarkCandidate) deduceInHouses() (bool, error) {
+	changedAny := false
+	for {
+		changed := false
+		for _, group := range c.houses.groups {
+			hiddenChanged, err := c.deduceHiddenSingles(group)
+			if err != nil {
+				return false, err
+			}
+			nakedChanged, err := c.deduceNakedSubsets(group)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || hiddenChanged || nakedChanged
+		}
+		for _, intersection := range c.houses.intersections {
+			first, second := c.houses.groups[intersection.first], c.houses.groups[intersection.second]
+			pointingChanged, err := c.deduceLockedValues(first, second, intersection.shared)
+			if err != nil {
+				return false, err
+			}
+			claimingChanged, err := c.deduceLockedValues(second, first, intersection.shared)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || pointingChanged || claimingChanged
+		}
+		if !changed {
+			return changedAny, nil
+		}
+		changedAny = true
+	}
+}
+
+func (c *pencilmarkCandidate) deduceHiddenSingles(group []sudoku.Coordinate) (bool, error) {
+	changed := false
+	for _, value := range c.sudok.PossibleValues {
+		placed := false
+		var possibleIn sudoku.Coordinate
+		count := 0
+		for _, coord := range group {
+			state := c.cellsState.At(coord)
+			if state.HasValue {
+				if state.Value == value {
+					placed = true
+					break
+				}
+				continue
+			}
+			if state.Possibilities.Contains(value) {
+				possibleIn = coord
+				count++
+			}
+		}
+		if placed {
+			continue
+		}
+		if count == 0 {
+			return false, fmt.Errorf("value %d has no place left in %v", value, group)
+		}
+		if count > 1 {
+			continue
+		}
+		cellChanged, err := c.restrictTo(possibleIn, newValueSet(value))
+		if err != nil {
+			return false, err
+		}
+		changed = changed || cellChanged
+	}
+	return changed, nil
+}
+
+// deduceNakedSubsets looks for two or three empty cells of the group that
+// together only have as many possibilities as there are cells.
+func (c *pencilmarkCandidate) deduceNakedSubsets(group []sudoku.Coordinate) (bool, error) {
+	empty := make([]sudoku.Coordinate, 0, len(group))
+	for _, coord := range group {
+		if !c.cellsState.At(coord).HasValue {
+			empty = append(empty, coord)
+		}
+	}
+	changed := false
+	for size := 2; size <= 3 && size < len(empty); size++ {
+		subset := make([]int, 0, size)
+		var search func(start int, union valueSet) error
+		search = func(start int, union valueSet) error {
+			if len(subset) == size {
+				if union.Len() < size {
+					return fmt.Errorf("%d cells of %v only have %d possibilities", size, group, union.Len())
+				}
+				if union.Len() > size {
+					return nil
+				}
+				for i, coord := range empty {
+					if slices.Contains(subset, i) {
+						continue
+					}
+					cellChanged, err := c.restrictTo(coord, ^union)
+					if err != nil {
+						return err
+					}
+					changed = changed || cellChanged
+				}
+				return nil
+			}
+			for i := start; i < len(empty); i++ {
+				possibilities := c.cellsState.At(empty[i]).Possibilities
+				if possibilities.Len() > size {
+					continue
+				}
+				if (union | possibilities).Len() > size {
+					continue
+				}
+				subset = append(subset, i)
+				err := search(i+1, union|possibilities)
+				subset = subset[:len(subset)-1]
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := search(0, 0); err != nil {
+			return false, err
+		}
+	}
+	return changed, nil
+}
+
+// deduceLockedValues removes every value from the cells of other that are not
+// shared with house, if the value is only possible in the shared cells of
+// house.
+func (c *pencilmarkCandidate) deduceLockedValues(house, other, shared []sudoku.Coordinate) (bool, error) {
+	changed := false
+	for _, value := range c.sudok.PossibleValues {
+		locked := true
+		possible := false
+		for _, coord := range house {
+			state := c.cellsState.At(coord)
+			if state.HasValue {
+				if state.Value == value {
+					locked = false
+					break
+				}
+				continue
+			}
+			if !state.Possibilities.Contains(value) {
+				continue
+			}
+			if !slices.Contains(shared, coord) {
+				locked = false
+				break
+			}
+			possible = true
+		}
+		if !locked || !possible {
+			continue
+		}
+		for _, coord := range other {
+			if slices.Contains(shared, coord) {
+				continue
+			}
+			cellChanged, err := c.restrictTo(coord, ^newValueSet(value))
+			if err != nil {
+				return false, err
+			}
+			changed = changed || cellChanged
+		}
+	}
+	return changed, nil
+}
+
+// restrictTo only keeps the allowed possibilities of an empty cell. Cells
+// with a value are not changed. It returns whether the cell changed.
+func (c *pencilmarkCandidate) restrictTo(coord sudoku.Coordinate, allowed valueSet) (bool, error) {
+	state := c.cellsState.At(coord)
+	if state.HasValue || state.Possibilities&allowed == state.Possibilities {
+		return false, nil
+	}
+	updated, stillSolvable := state.withConstrainedSet(allowed)
+	if !stillSolvable {
+		return false, fmt.Errorf("coordinate %v has no possibilities left", coord)
+	}
+	c.cellsState.Set(coord, updated)
+	return true, nil
+}
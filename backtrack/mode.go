@@ -2,6 +2,7 @@ package backtrack
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"sudoku-solver/sudoku"
 )
@@ -11,25 +12,117 @@ type Mode string
 const (
 	ModeSimple     Mode = "simple"
 	ModePencilMark Mode = "pencilmark"
+	// ModePencilMarkMRV works like ModePencilMark but always branches on the
+	// coordinate with the fewest possibilities left.
+	ModePencilMarkMRV Mode = "pencilmark-mrv"
+	ModeChaos         Mode = "chaos"
+	ModeDLX           Mode = "dlx"
+	// ModeTrail propagates like ModePencilMarkMRV but changes a single state
+	// and undoes the changes when backtracking instead of copying the state.
+	ModeTrail Mode = "trail"
+	// ModeGAC branches like ModePencilMarkMRV but makes all constraints
+	// generalized arc consistent after every placement. Constraints that
+	// don't implement sudoku.Supporter are only propagated.
+	ModeGAC Mode = "gac"
+	// ModeSAT encodes the sudoku as CNF and solves it with a CDCL SAT solver.
+	ModeSAT Mode = "sat"
+	// ModeNogood works like ModePencilMarkMRV but learns from every value
+	// that fails and every cell without a value left which placed values
+	// caused the failure and prunes all candidates that have the same values.
+	// It keeps at most DefaultNogoodLimit nogoods, see NogoodMode.
+	ModeNogood Mode = "nogood"
 )
 
-func ParseMode(s string) (Mode, error) {
+// NogoodMode returns ModeNogood with a different limit of the nogoods it
+// keeps during a search. When the store is full, the oldest nogood is
+// forgotten. Zero or less means that there is no limit.
+func NogoodMode(limit int) Mode {
+	return Mode(fmt.Sprintf("%s:%d", ModeNogood, limit))
+}
+
+// name returns the mode without its settings.
+func (m Mode) name() Mode {
+	name, _, _ := strings.Cut(string(m), ":")
+	return Mode(name)
+}
+
+// nogoodLimit returns the limit of a mode created with NogoodMode or
+// DefaultNogoodLimit.
+func (m Mode) nogoodLimit() int {
+	_, setting, ok := strings.Cut(string(m), ":")
+	if !ok {
+		return DefaultNogoodLimit
+	}
+	limit, err := strconv.Atoi(setting)
+	if err != nil {
+		return DefaultNogoodLimit
+	}
+	return limit
+}
+
+// ParseMode parses the name of a mode and checks that the mode can solve the
+// given sudoku.
+func ParseMode(s string, sudok sudoku.Sudoku) (Mode, error) {
+	mode, err := parseModeName(s)
+	if err != nil {
+		return "", err
+	}
+	if err := mode.Check(sudok); err != nil {
+		return "", err
+	}
+	return mode, nil
+}
+
+func MustParseMode(s string, sudok sudoku.Sudoku) Mode {
+	mode, err := ParseMode(s, sudok)
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+func parseModeName(s string) (Mode, error) {
 	switch strings.ToLower(s) {
 	case string(ModeSimple):
 		return ModeSimple, nil
 	case string(ModePencilMark):
 		return ModePencilMark, nil
+	case string(ModePencilMarkMRV):
+		return ModePencilMarkMRV, nil
+	case string(ModeChaos):
+		return ModeChaos, nil
+	case string(ModeDLX):
+		return ModeDLX, nil
+	case string(ModeTrail):
+		return ModeTrail, nil
+	case string(ModeGAC):
+		return ModeGAC, nil
+	case string(ModeSAT):
+		return ModeSAT, nil
+	case string(ModeNogood):
+		return ModeNogood, nil
 	default:
 		return "", fmt.Errorf("unknown mode: %s", s)
 	}
 }
 
-func MustParseMode(s string) Mode {
-	mode, err := ParseMode(s)
-	if err != nil {
-		panic(err)
+// Check returns an error if the mode can't solve the given sudoku.
+func (m Mode) Check(sudok sudoku.Sudoku) error {
+	if m.name() == ModeChaos {
+		if sudok.RegionCount <= 0 {
+			return fmt.Errorf("mode %s needs a sudoku with unknown regions", m)
+		}
+		return nil
 	}
-	return mode
+	if sudok.RegionCount > 0 {
+		return fmt.Errorf("mode %s can't solve sudokus with unknown regions, use mode %s", m, ModeChaos)
+	}
+	if m.name() == ModeDLX {
+		if err := canUseDLX(sudok); err != nil {
+			return fmt.Errorf("mode %s: %w", m, err)
+		}
+	}
+	return nil
 }
 
 func (m Mode) String() string {
@@ -37,22 +130,66 @@ func (m Mode) String() string {
 }
 
 func (m Mode) RootCandidate(sudok sudoku.Sudoku) Candidate {
-	switch m {
+	root, err := m.rootCandidate(sudok)
+	if err != nil {
+		// TODO: better error handling
+		panic(err)
+	}
+	return root
+}
+
+// rootCandidate creates the root of the search. The error wraps errNoSolution
+// if the givens contradict each other.
+func (m Mode) rootCandidate(sudok sudoku.Sudoku) (Candidate, error) {
+	if err := m.Check(sudok); err != nil {
+		return nil, err
+	}
+	switch m.name() {
 	case ModeSimple:
-		root, err := rootSimple(sudok)
-		if err != nil {
-			// TODO: better error handling
-			panic(err)
-		}
-		return root
+		return rootSimple(sudok)
 	case ModePencilMark:
-		root, err := rootPencilMark(sudok)
+		return rootPencilMark(sudok, selectInOrder, propagateConstraints)
+	case ModePencilMarkMRV:
+		return rootPencilMark(sudok, selectMostConstrained, propagateConstraints)
+	case ModeGAC:
+		return rootPencilMark(sudok, selectMostConstrained, enforceArcConsistency)
+	case ModeNogood:
+		root, err := rootPencilMark(sudok, selectMostConstrained, propagateConstraints)
 		if err != nil {
-			// TODO: better error handling
-			panic(err)
+			return nil, err
+		}
+		pc := root.(*pencilmarkCandidate)
+		pc.nogoods = newNogoodStore(pc, m.nogoodLimit())
+		return pc, nil
+	case ModeChaos:
+		return rootChaos(sudok)
+	case ModeDLX, ModeTrail, ModeSAT:
+		return nil, fmt.Errorf("mode %s does not search candidates", m)
+	default:
+		return nil, fmt.Errorf("unknown mode: %s", m)
+	}
+}
+
+// engine returns the engine of modes that don't search a tree of candidates
+// and nil for all other modes.
+func (m Mode) engine(sudok sudoku.Sudoku) (engine, error) {
+	switch m.name() {
+	case ModeDLX:
+		if err := m.Check(sudok); err != nil {
+			return nil, err
+		}
+		return newDLXMatrix(sudok)
+	case ModeTrail:
+		if err := m.Check(sudok); err != nil {
+			return nil, err
+		}
+		return newTrailSearch(sudok)
+	case ModeSAT:
+		if err := m.Check(sudok); err != nil {
+			return nil, err
 		}
-		return root
+		return newSATSearch(sudok)
 	default:
-		panic(fmt.Errorf("unknown mode: %s", m))
+		return nil, nil
 	}
 }
@@ -0,0 +1,187 @@
+package backtrack
+
+import (
+	"slices"
+	"sudoku-solver/sudoku"
+	"sync"
+)
+
+// DefaultNogoodLimit is the maximum number of nogoods ModeNogood keeps
+// during a search, see NogoodMode.
+const DefaultNogoodLimit = 10000
+
+// assignment is a value placed at a coordinate.
+type assignment struct {
+	coordinate sudoku.Coordinate
+	value      int
+}
+
+// nogoodStore holds sets of assignments that can't be part of a solution. A
+// nogood is learned whenever placing a value fails. The decisions that led to
+// the failure are reduced to the ones that are needed to reproduce it from
+// the root, so that the nogood also prunes other branches of the search.
+// The store is shared by all candidates of a search, which can run in
+// parallel.
+type nogoodStore struct {
+	// root is the candidate the failures are reproduced from
+	root  *pencilmarkCandidate
+	limit int
+
+	mu      sync.RWMutex
+	nogoods [][]assignment
+	// watches holds the positions of the nogoods that contain an assignment
+	watches map[assignment][]int
+	// next is the position of the next nogood to replace once the store is
+	// full
+	next int
+	// unsolvable is true once the root fails without any decision, so that
+	// every candidate is pruned
+	unsolvable bool
+}
+
+func newNogoodStore(root *pencilmarkCandidate, limit int) *nogoodStore {
+	return &nogoodStore{
+		root:    root.child(),
+		limit:   limit,
+		watches: make(map[assignment][]int),
+	}
+}
+
+// place places the value of the assignment in the candidate and returns
+// false if that fails.
+func (s *nogoodStore) place(candidate *pencilmarkCandidate, a assignment) bool {
+	state := candidate.cellsState.At(a.coordinate)
+	if state.HasValue {
+		return state.Value == a.value
+	}
+	if !state.Possibilities.Contains(a.value) {
+		return false
+	}
+	filled := len(candidate.filled)
+	if err := candidate.FillIn(a.coordinate, a.value); err != nil {
+		return false
+	}
+	return !candidate.index.IsViolatedAt(candidate, candidate.filled[filled:]...)
+}
+
+// learn reduces the decisions to a nogood and stores it. failed reports
+// whether a candidate with some of the decisions fails; placing a value that
+// fails counts as well. The decisions are placed in order until the candidate
+// fails. The last decision placed belongs to the nogood, so the search starts
+// over with the decisions of the nogood and only the decisions before it.
+// This replays the failure once per decision of the nogood.
+func (s *nogoodStore) learn(decisions []assignment, failed func(*pencilmarkCandidate) bool) {
+	var needed []int
+	rest := len(decisions)
+	for {
+		candidate := s.root.child()
+		fails := func(a assignment) bool { return !s.place(candidate, a) || failed(candidate) }
+		if failed(candidate) || slices.ContainsFunc(needed, func(i int) bool { return fails(decisions[i]) }) {
+			break
+		}
+		culprit := slices.IndexFunc(decisions[:rest], fails)
+		if culprit < 0 {
+			// the failure depends on the order of the decisions, keep all
+			// of them
+			for i := 0; i < rest; i++ {
+				needed = append(needed, i)
+			}
+			break
+		}
+		needed = append(needed, culprit)
+		rest = culprit
+	}
+	if len(needed) == 0 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.unsolvable = true
+		return
+	}
+	slices.Sort(needed)
+	nogood := make([]assignment, 0, len(needed))
+	for _, i := range needed {
+		nogood = append(nogood, decisions[i])
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limit <= 0 || len(s.nogoods) < s.limit {
+		s.watch(nogood, len(s.nogoods))
+		s.nogoods = append(s.nogoods, nogood)
+		return
+	}
+	for _, a := range s.nogoods[s.next] {
+		s.watches[a] = slices.DeleteFunc(s.watches[a], func(position int) bool { return position == s.next })
+		if len(s.watches[a]) == 0 {
+			delete(s.watches, a)
+		}
+	}
+	s.watch(nogood, s.next)
+	s.nogoods[s.next] = nogood
+	s.next = (s.next + 1) % s.limit
+}
+
+// watch adds the position of the nogood to the watches of its assignments.
+func (s *nogoodStore) watch(nogood []assignment, position int) {
+	for _, a := range nogood {
+		s.watches[a] = append(s.watches[a], position)
+	}
+}
+
+// learnFailure learns from decisions whose last value failed to be placed.
+func (s *nogoodStore) learnFailure(decisions []assignment) {
+	s.learn(decisions, func(*pencilmarkCandidate) bool { return false })
+}
+
+// learnDeadEnd learns from decisions after which no value of the coordinate
+// can be placed.
+func (s *nogoodStore) learnDeadEnd(decisions []assignment, coord sudoku.Coordinate) {
+	s.learn(decisions, func(c *pencilmarkCandidate) bool {
+		state := c.cellsState.At(coord)
+		if state.HasValue {
+			return false
+		}
+		for _, value := range state.Possibilities.Values() {
+			child := c.child()
+			if child.FillIn(coord, value) == nil && !s.prunes(child) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// prunes returns true if the candidate has all assignments of a nogood. Only
+// the nogoods with a value that was filled in since the parent candidate are
+// checked, because the other nogoods would have pruned the parent already.
+// A nogood that was learned after the parent was checked can be missed, which
+// only prunes less.
+func (s *nogoodStore) prunes(c *pencilmarkCandidate) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.unsolvable {
+		return true
+	}
+	for _, coord := range c.filled {
+		value, ok := c.cellsState.Get(coord)
+		if !ok {
+			continue
+		}
+		for _, position := range s.watches[assignment{coordinate: coord, value: value}] {
+			if s.contains(c, s.nogoods[position]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contains returns true if the candidate has all assignments of the nogood.
+func (s *nogoodStore) contains(c *pencilmarkCandidate, nogood []assignment) bool {
+	for _, a := range nogood {
+		if value, ok := c.cellsState.Get(a.coordinate); !ok || value != a.value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package backtrack
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sudoku-solver/sudoku"
+	"sync"
+	"sync/atomic"
+)
+
+// candidateDeque holds the candidates a worker still has to visit. The owner
+// works depth first on the bottom, other workers steal from the top, where
+// the candidates closest to the root and therefore the biggest subtrees are.
+type candidateDeque struct {
+	mu         sync.Mutex
+	candidates []Candidate
+}
+
+func (d *candidateDeque) pushBottom(candidates ...Candidate) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.candidates = append(d.candidates, candidates...)
+}
+
+func (d *candidateDeque) popBottom() (Candidate, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.candidates) == 0 {
+		return nil, false
+	}
+	last := len(d.candidates) - 1
+	candidate := d.candidates[last]
+	d.candidates[last] = nil
+	d.candidates = d.candidates[:last]
+	return candidate, true
+}
+
+func (d *candidateDeque) popTop() (Candidate, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.candidates) == 0 {
+		return nil, false
+	}
+	candidate := d.candidates[0]
+	d.candidates[0] = nil
+	d.candidates = d.candidates[1:]
+	return candidate, true
+}
+
+func (d *candidateDeque) isEmpty() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.candidates) == 0
+}
+
+type parallelBacktracker struct {
+	checker   violationChecker
+	solutions chan sudoku.Solution
+	deques    []candidateDeque
+	// pending is the number of candidates that were found but not visited yet.
+	// The search is done when it drops to zero.
+	pending atomic.Int64
+
+	// workers without candidates wait on idle until candidates are pushed,
+	// pending drops to zero or the context is cancelled
+	mu      sync.Mutex
+	idle    *sync.Cond
+	waiting atomic.Int64
+}
+
+// FindSolutionsParallel works like FindSolutions but lets the given number of
+// workers search the candidate tree. Every worker searches its own subtrees
+// depth first and steals subtrees close to the root from the other workers
+// when it runs out of work. The order of the solutions is not deterministic.
+// If workers is less than one, GOMAXPROCS workers are used. Modes that don't
+// search candidates (like ModeDLX) fall back to FindSolutions.
+func FindSolutionsParallel(ctx context.Context, mode Mode, sudok sudoku.Sudoku, workers int) (<-chan sudoku.Solution, error) {
+	e, err := mode.engine(sudok)
+	if errors.Is(err, errNoSolution) {
+		solutions := make(chan sudoku.Solution)
+		close(solutions)
+		return solutions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e != nil {
+		return findSolutionsWithEngine(ctx, e, sudok), nil
+	}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	root, err := mode.rootCandidate(sudok)
+	if errors.Is(err, errNoSolution) {
+		solutions := make(chan sudoku.Solution)
+		close(solutions)
+		return solutions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &parallelBacktracker{
+		checker:   newViolationChecker(sudok),
+		solutions: make(chan sudoku.Solution, workers),
+		deques:    make([]candidateDeque, workers),
+	}
+	p.idle = sync.NewCond(&p.mu)
+	p.pending.Add(1)
+	p.deques[0].pushBottom(root)
+	stop := context.AfterFunc(ctx, p.wakeAll)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			p.work(ctx, worker)
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		stop()
+		close(p.solutions)
+	}()
+	return p.solutions, nil
+}
+
+func (p *parallelBacktracker) work(ctx context.Context, worker int) {
+	for ctx.Err() == nil {
+		candidate, ok := p.deques[worker].popBottom()
+		if !ok {
+			candidate, ok = p.steal(worker)
+		}
+		if !ok {
+			if p.pending.Load() == 0 {
+				return
+			}
+			// somebody else is still expanding candidates, wait for them
+			p.wait(ctx)
+			continue
+		}
+		p.visit(ctx, worker, candidate)
+		if p.pending.Add(-1) == 0 {
+			p.wakeAll()
+		}
+	}
+}
+
+// wait blocks until a deque has candidates, pending is zero or the context is
+// cancelled. Pushing workers only wake the others if one of them is waiting,
+// so waiting is announced before the deques are checked.
+func (p *parallelBacktracker) wait(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+	for ctx.Err() == nil && p.pending.Load() > 0 && !p.hasCandidates() {
+		p.idle.Wait()
+	}
+}
+
+func (p *parallelBacktracker) hasCandidates() bool {
+	for i := range p.deques {
+		if !p.deques[i].isEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+// wakeAll wakes all waiting workers, so that they check for candidates again.
+func (p *parallelBacktracker) wakeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle.Broadcast()
+}
+
+// steal takes the candidate closest to the root from the first other worker
+// that has one.
+func (p *parallelBacktracker) steal(thief int) (Candidate, bool) {
+	for i := 1; i < len(p.deques); i++ {
+		victim := (thief + i) % len(p.deques)
+		if candidate, ok := p.deques[victim].popTop(); ok {
+			return candidate, true
+		}
+	}
+	return nil, false
+}
+
+func (p *parallelBacktracker) visit(ctx context.Context, worker int, candidate Candidate) {
+	if p.checker.isViolated(candidate) {
+		return
+	}
+	if p.checker.isSolved(candidate) {
+		select {
+		case <-ctx.Done():
+		case p.solutions <- candidate:
+		}
+		return
+	}
+	nextCandidates := candidate.NextCandidates()
+	if len(nextCandidates) == 0 {
+		return
+	}
+	// push in reverse, so that the first candidate is visited first
+	reversed := make([]Candidate, len(nextCandidates))
+	for i, nextCandidate := range nextCandidates {
+		reversed[len(nextCandidates)-1-i] = nextCandidate
+	}
+	p.pending.Add(int64(len(reversed)))
+	p.deques[worker].pushBottom(reversed...)
+	if p.waiting.Load() > 0 {
+		p.wakeAll()
+	}
+}
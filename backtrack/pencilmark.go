@@ -5,13 +5,23 @@ import (
 	"slices"
 	"sudoku-solver/constraint"
 	"sudoku-solver/sudoku"
-
-	"golang.org/x/exp/maps"
 )
 
 type pencilmarkCandidate struct {
 	cellsState
-	sudok sudoku.Sudoku
+	sudok      sudoku.Sudoku
+	selectCell cellSelection
+	propagate  propagation
+	index      *sudoku.ConstraintIndex
+	houses     *houses
+	// filled holds the coordinates that were filled in since the parent
+	// candidate. It is nil for the root, which has no parent.
+	filled []sudoku.Coordinate
+	// decisions holds the values that were tried on the way from the root to
+	// this candidate
+	decisions []assignment
+	// nogoods is nil if the search doesn't learn nogoods
+	nogoods *nogoodStore
 
 	coordinateIndex int
 }
@@ -19,133 +29,145 @@ type pencilmarkCandidate struct {
 var _ Candidate = (*pencilmarkCandidate)(nil)
 
 func (c *pencilmarkCandidate) FillIn(coordinate sudoku.Coordinate, value int) error {
-	if _, ok := c.cellsState[coordinate]; !ok {
+	if _, ok := c.cellsState.Lookup(coordinate); !ok {
 		return fmt.Errorf("coordinate %v not found in state", coordinate)
 	}
-	c.cellsState[coordinate] = FixedCellState(value)
-	for _, constr := range c.sudok.Constraints {
-		switch constr := constr.(type) {
-		case constraint.NoRepeatConstraint:
-			if err := c.updateWithNoRepeatConstraint(constr, coordinate, value); err != nil {
-				return fmt.Errorf("update with no repeat constraint: %w", err)
+	c.cellsState.Set(coordinate, FixedCellState(value))
+	if c.filled != nil {
+		c.filled = append(c.filled, coordinate)
+	}
+	return c.propagate(c, []sudoku.Coordinate{coordinate})
+}
+
+// propagation narrows down the possibilities of a pencilmark candidate after
+// values were placed at the given coordinates. For the root all coordinates
+// are given.
+type propagation func(c *pencilmarkCandidate, coordinates []sudoku.Coordinate) error
+
+// propagateConstraints lets the constraints of the coordinates narrow down
+// the possibilities of their cells and then fills in singles.
+func propagateConstraints(c *pencilmarkCandidate, coordinates []sudoku.Coordinate) error {
+	// only the constraints of these coordinates can narrow down other cells
+	propagated := make([]bool, c.index.Len())
+	for _, coordinate := range coordinates {
+		for _, position := range c.index.Positions(coordinate) {
+			if propagated[position] {
+				continue
+			}
+			propagated[position] = true
+			constr := c.index.Constraint(position)
+			propagator, ok := constr.(sudoku.Propagator)
+			if !ok {
+				continue
 			}
-		case constraint.SameSumConstraint:
-			if err := c.updateWithSameSumConstraint(constr, coordinate, value); err != nil {
-				return fmt.Errorf("update with same sum constraint: %w", err)
+			if err := propagator.Propagate(c); err != nil {
+				return fmt.Errorf("propagate %T: %w", constr, err)
 			}
-		default:
 		}
 	}
-	// Since there was no error after the fill in let's check if we can fill in any
-	// other values because of this fill in.
-	for coord, state := range c.cellsState {
-		if state.HasValue {
-			continue
-		}
-		if len(state.Possibilities) == 0 {
-			// we can't fill in any values anymore, so this candidate is not solvable
-			// this should never happen
-			panic(fmt.Sprintf("coordinate %v has no possibilities left", coord))
-		}
-		if len(state.Possibilities) > 1 {
-			// we can't fill in any values yet
-			continue
+	return c.fillInSingles()
+}
+
+// fillInSingles fills in the cells with a single possibility left. Once there
+// are none left, the houses are used to narrow down the possibilities until
+// new singles show up or nothing changes anymore.
+func (c *pencilmarkCandidate) fillInSingles() error {
+	for {
+		for i, state := range c.cells {
+			coord := c.grid.coordinates[i]
+			if state.HasValue {
+				continue
+			}
+			if state.Possibilities == 0 {
+				// we can't fill in any values anymore, so this candidate is not solvable
+				// this should never happen
+				panic(fmt.Sprintf("coordinate %v has no possibilities left", coord))
+			}
+			if state.Possibilities.Len() > 1 {
+				// we can't fill in any values yet
+				continue
+			}
+			// we can fill in this value
+			err := c.FillIn(coord, state.Possibilities.Min())
+			if err != nil {
+				return fmt.Errorf("fill in %d at %v: %w", state.Possibilities.Min(), coord, err)
+			}
+			return nil
 		}
-		// we can fill in this value
-		err := c.FillIn(coord, state.Possibilities[0])
+		changed, err := c.deduceInHouses()
 		if err != nil {
-			return fmt.Errorf("fill in %d at %v: %w", state.Possibilities[0], coord, err)
+			return fmt.Errorf("deduce in houses: %w", err)
+		}
+		if !changed {
+			// we can't fill in any other values, so we are done
+			return nil
 		}
-		return nil
 	}
-	// we can't fill in any other values, so we are done
-	return nil
 }
 
-func (c *pencilmarkCandidate) updateWithNoRepeatConstraint(constr constraint.NoRepeatConstraint, coordinate sudoku.Coordinate, value int) error {
-	// In a NoRepeatConstraint, we need to remove the value from the possibilities
-	// of all other coordinates in the constraint.
-	if !slices.Contains(constr.ConstrainedCoordinates(), coordinate) {
-		return nil
-	}
-	for _, coor := range constr.ConstrainedCoordinates() {
-		coorState := c.cellsState[coor]
-		updated, stillSolvable := coorState.WithRemovedPossibilities(value)
-		if !stillSolvable {
-			return fmt.Errorf("coordinate %v is no longer solvable", coor)
-		}
-		c.cellsState[coor] = updated
+var _ sudoku.Domains = (*pencilmarkCandidate)(nil)
+
+func (c *pencilmarkCandidate) Candidates(coordinate sudoku.Coordinate) []int {
+	state := c.cellsState.At(coordinate)
+	if state.HasValue {
+		return []int{state.Value}
 	}
-	return nil
+	return state.Possibilities.Values()
 }
 
-func (c *pencilmarkCandidate) updateWithSameSumConstraint(constr constraint.SameSumConstraint, coordinate sudoku.Coordinate, value int) error {
-	// Since this is easily expandable we just start with the case
-	// where we have an arrow and something was written on any of the
-	// path coordinates.
-	if len(constr.Coordinates1) != 1 {
-		// this is not an arrow constraint
-		return nil
-	}
-	circle := constr.Coordinates1[0]
-	if circle == coordinate {
-		// we filled in the circle coordinate, so for now we don't
-		// care about this case
-		return nil
+func (c *pencilmarkCandidate) CanBe(coordinate sudoku.Coordinate, value int) bool {
+	state := c.cellsState.At(coordinate)
+	if state.HasValue {
+		return state.Value == value
 	}
-	circleState, ok := c.cellsState[circle]
+	return state.Possibilities.Contains(value)
+}
+
+func (c *pencilmarkCandidate) Bounds(coordinate sudoku.Coordinate) (int, int) {
+	return c.cellsState.At(coordinate).Bounds()
+}
+
+func (c *pencilmarkCandidate) Restrict(coordinate sudoku.Coordinate, keep func(int) bool) error {
+	state, ok := c.cellsState.Lookup(coordinate)
 	if !ok {
-		// this should never happen
-		panic(fmt.Sprintf("coordinate %v not found in state", circle))
-	}
-	if circleState.HasValue {
-		// we filled in the circle coordinate already, so for now we don't
-		// care about this case
-		return nil
+		return fmt.Errorf("coordinate %v not found in state", coordinate)
 	}
-	path := constr.Coordinates2
-	if !slices.Contains(path, coordinate) {
-		// the coordinate we filled in is not on the path, so for now
-		// we don't care about this case
+	if state.HasValue {
+		if !keep(state.Value) {
+			return fmt.Errorf("value %d at %v is not allowed", state.Value, coordinate)
+		}
 		return nil
 	}
-	// we filled in a coordinate on the path, so we need can maybe remove
-	// some possibilities from the circle coordinate
-	// so we just calculate all the possible sums and remove all values
-	// that are not possible anymore
-	possibilitiesSlice := Map(path, func(coor sudoku.Coordinate) []int {
-		coorState := c.cellsState[coor]
-		if coorState.HasValue {
-			return []int{coorState.Value}
-		}
-		return coorState.Possibilities
-	})
-	possibleSums := make(map[int]struct{})
-	for _, valueCombination := range Combinations(possibilitiesSlice) {
-		sum := Reduce(valueCombination, 0, func(acc, value int) int {
-			return acc + value
-		})
-		possibleSums[sum] = struct{}{}
-	}
-	newCircleState, stillSolvable := c.cellsState[circle].WithConstrainedPossibilities(maps.Keys(possibleSums)...)
+	updated, stillSolvable := state.withConstrainedSet(state.Possibilities.Filter(keep))
 	if !stillSolvable {
-		return fmt.Errorf("arrow circle coordinate %v is no longer solvable", circle)
+		return fmt.Errorf("coordinate %v has no possibilities left", coordinate)
 	}
-	c.cellsState[circle] = newCircleState
+	c.cellsState.Set(coordinate, updated)
 	return nil
 }
 
-func rootPencilMark(sudok sudoku.Sudoku) (Candidate, error) {
+func rootPencilMark(sudok sudoku.Sudoku, selectCell cellSelection, propagate propagation) (Candidate, error) {
+	grid, err := newCellGrid(sudok)
+	if err != nil {
+		return nil, fmt.Errorf("create grid: %w", err)
+	}
 	candidate := &pencilmarkCandidate{
-		cellsState:      make(cellsState, len(sudok.Coordinates)),
+		cellsState:      newCellsState(grid, sudok.PossibleValues),
 		sudok:           sudok,
+		selectCell:      selectCell,
+		propagate:       propagate,
+		index:           sudok.ConstraintIndex(),
+		houses:          newHouses(sudok),
 		coordinateIndex: 0,
 	}
-	for _, coor := range sudok.Coordinates {
-		candidate.cellsState[coor] = VariableCellState(sudok.PossibleValues)
+
+	// narrow down the possibilities before any value is placed, e.g. remove
+	// the biggest value from minimum cells
+	if err := propagate(candidate, sudok.Coordinates); err != nil {
+		return nil, fmt.Errorf("propagate: %w: %w", errNoSolution, err)
 	}
 
-	// first we fill in all fixed values
+	// then we fill in all fixed values
 	for _, constr := range sudok.Constraints {
 		fvc, ok := constr.(constraint.FixedValueConstraint)
 		if !ok {
@@ -154,49 +176,68 @@ func rootPencilMark(sudok sudoku.Sudoku) (Candidate, error) {
 
 		err := candidate.FillIn(fvc.Coordinate, fvc.Value)
 		if err != nil {
-			return nil, fmt.Errorf("fill in fixed value %d at %v: %w", fvc.Value, fvc.Coordinate, err)
+			return nil, fmt.Errorf("fill in fixed value %d at %v: %w: %w", fvc.Value, fvc.Coordinate, errNoSolution, err)
 		}
 	}
 
 	return candidate, nil
 }
 
-func (c *pencilmarkCandidate) NextCandidates() []Candidate {
-	// if this candidate belongs to the last coordinate then we are done
-	if c.coordinateIndex >= len(c.sudok.Coordinates)-1 {
-		return nil
+// child returns a copy of this candidate one level deeper in the search.
+func (c *pencilmarkCandidate) child() *pencilmarkCandidate {
+	return &pencilmarkCandidate{
+		cellsState:      c.cellsState.Copy(),
+		sudok:           c.sudok,
+		selectCell:      c.selectCell,
+		propagate:       c.propagate,
+		index:           c.index,
+		houses:          c.houses,
+		filled:          make([]sudoku.Coordinate, 0, 1),
+		decisions:       slices.Clip(c.decisions),
+		nogoods:         c.nogoods,
+		coordinateIndex: c.coordinateIndex + 1,
 	}
-	coord := c.sudok.Coordinates[c.coordinateIndex]
-	cell, ok := c.cellsState[coord]
+}
+
+// filledIn returns the coordinates that were filled in since the parent
+// candidate and false for the root.
+func (c *pencilmarkCandidate) filledIn() ([]sudoku.Coordinate, bool) {
+	return c.filled, c.filled != nil
+}
+
+func (c *pencilmarkCandidate) NextCandidates() []Candidate {
+	coord, ok := c.selectCell(c)
 	if !ok {
-		// this should never happen
-		// TODO: use slog here
-		panic(fmt.Sprintf("coordinate %v not found in state", coord))
-	}
-	if cell.HasValue {
-		// this coordinate is fixed, so we don't need to try any values
-		return []Candidate{&pencilmarkCandidate{
-			cellsState:      c.cellsState.Copy(),
-			sudok:           c.sudok,
-			coordinateIndex: c.coordinateIndex + 1,
-		}}
+		// all coordinates are filled in, so we are done
+		return nil
 	}
-
-	currentPossibilities := cell.Possibilities
+	currentPossibilities := c.cellsState.At(coord).Possibilities.Values()
 	nextCandidates := make([]Candidate, 0, len(currentPossibilities))
 	for _, value := range currentPossibilities {
 		// try to fill in the coordinate with the value
-		newCandidate := &pencilmarkCandidate{
-			cellsState:      c.cellsState.Copy(),
-			sudok:           c.sudok,
-			coordinateIndex: c.coordinateIndex + 1,
-		}
+		newCandidate := c.child()
+		newCandidate.decisions = append(newCandidate.decisions, assignment{coordinate: coord, value: value})
 		err := newCandidate.FillIn(coord, value)
+		if err == nil && c.nogoods != nil && c.index.IsViolatedAt(newCandidate, newCandidate.filled...) {
+			// the search would only find out when it visits the candidate
+			err = errNoSolution
+		}
 		if err != nil {
 			// filling in this value makes the sudoku unsolvable
+			if c.nogoods != nil {
+				c.nogoods.learnFailure(newCandidate.decisions)
+			}
+			continue
+		}
+		if c.nogoods != nil && c.nogoods.prunes(newCandidate) {
 			continue
 		}
 		nextCandidates = append(nextCandidates, newCandidate)
 	}
+	if len(nextCandidates) == 0 && c.nogoods != nil {
+		// no value of the coordinate can be placed, so the decisions can't be
+		// part of a solution either
+		c.nogoods.learnDeadEnd(c.decisions, coord)
+	}
 	return nextCandidates
 }
@@ -0,0 +1,141 @@
+package backtrack
+
+import (
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func emptyClassicSudoku(t *testing.T) sudoku.Sudoku {
+	sudok, err := sudokuio.ParseString(`
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---
+		--- --- ---`)
+	require.NoError(t, err)
+	return *sudok
+}
+
+func TestRootPencilMarkPrunesExtremes(t *testing.T) {
+	sudok := emptyClassicSudoku(t)
+	minimum := sudoku.Coordinate{Row: 5, Col: 5}
+	maximum := sudoku.Coordinate{Row: 1, Col: 1}
+	minimumConstraints, err := constraint.NewMinimumConstraints(minimum, sudok.Coordinates)
+	require.NoError(t, err)
+	for _, c := range minimumConstraints {
+		sudok.Constraints = append(sudok.Constraints, c)
+	}
+	maximumConstraints, err := constraint.NewMaximumConstraints(maximum, sudok.Coordinates)
+	require.NoError(t, err)
+	for _, c := range maximumConstraints {
+		sudok.Constraints = append(sudok.Constraints, c)
+	}
+
+	root, err := rootPencilMark(sudok, selectInOrder, propagateConstraints)
+	require.NoError(t, err)
+	state := root.(*pencilmarkCandidate).cellsState
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, state.At(minimum).Possibilities.Values())
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9}, state.At(sudoku.Coordinate{Row: 4, Col: 5}).Possibilities.Values())
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9}, state.At(maximum).Possibilities.Values())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, state.At(sudoku.Coordinate{Row: 1, Col: 2}).Possibilities.Values())
+}
+
+func TestRootPencilMarkSolvesClassicSudokuWithoutBranching(t *testing.T) {
+	sudok, err := sudokuio.ParseString(`
+		24- --- -86
+		--3 --- ---
+		1-- --2 5--
+		59- -1- --2
+		--7 --- 3--
+		8-- -4- -97
+		--5 8-- --3
+		--- --- 6--
+		32- --- -19`)
+	require.NoError(t, err)
+
+	root, err := rootPencilMark(*sudok, selectInOrder, propagateConstraints)
+	require.NoError(t, err)
+	require.NoError(t, sudok.Check(root))
+	assert.Empty(t, root.NextCandidates())
+}
+
+func TestHousesFindHiddenSingle(t *testing.T) {
+	sudok := emptyClassicSudoku(t)
+	// 1 is placed in the first two rows of the last two boxes of the top, so
+	// in the first box it can only go into the first cell of the third row
+	// once the other two cells of that row are filled in
+	for _, fvc := range []constraint.FixedValueConstraint{
+		{Coordinate: sudoku.Coordinate{Row: 1, Col: 5}, Value: 1},
+		{Coordinate: sudoku.Coordinate{Row: 2, Col: 8}, Value: 1},
+		{Coordinate: sudoku.Coordinate{Row: 3, Col: 2}, Value: 2},
+		{Coordinate: sudoku.Coordinate{Row: 3, Col: 3}, Value: 3},
+	} {
+		sudok.Constraints = append(sudok.Constraints, fvc)
+	}
+
+	root, err := rootPencilMark(sudok, selectInOrder, propagateConstraints)
+	require.NoError(t, err)
+	value, ok := root.Get(sudoku.Coordinate{Row: 3, Col: 1})
+	require.True(t, ok)
+	assert.Equal(t, 1, value)
+}
+
+func TestArcConsistencySearchesSupportOfExpressions(t *testing.T) {
+	sudok := emptyClassicSudoku(t)
+	expression, err := constraint.NewExpressionConstraint("R1C1 * R1C2 == 8 && R1C1 < R1C2")
+	require.NoError(t, err)
+	sudok.Constraints = append(sudok.Constraints, expression)
+
+	root, err := rootPencilMark(sudok, selectMostConstrained, enforceArcConsistency)
+	require.NoError(t, err)
+	state := root.(*pencilmarkCandidate).cellsState
+	assert.Equal(t, []int{1, 2}, state.At(sudoku.Coordinate{Row: 1, Col: 1}).Possibilities.Values())
+	assert.Equal(t, []int{4, 8}, state.At(sudoku.Coordinate{Row: 1, Col: 2}).Possibilities.Values())
+}
+
+// propagateOnly hides the Supported method of a constraint.
+type propagateOnly struct {
+	sudoku.Constraint
+	sudoku.Propagator
+}
+
+func TestArcConsistencyPropagatesConstraintsWithoutSupport(t *testing.T) {
+	sudok := emptyClassicSudoku(t)
+	sum := constraint.SumConstraint{Coordinates: []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}}, Sum: 3}
+	sudok.Constraints = append(sudok.Constraints, propagateOnly{Constraint: sum, Propagator: sum})
+
+	root, err := rootPencilMark(sudok, selectMostConstrained, enforceArcConsistency)
+	require.NoError(t, err)
+	state := root.(*pencilmarkCandidate).cellsState
+	assert.Equal(t, []int{1, 2}, state.At(sudoku.Coordinate{Row: 1, Col: 1}).Possibilities.Values())
+	assert.Equal(t, []int{1, 2}, state.At(sudoku.Coordinate{Row: 1, Col: 2}).Possibilities.Values())
+	// the changed cells are revised in their row again
+	assert.Equal(t, []int{3, 4, 5, 6, 7, 8, 9}, state.At(sudoku.Coordinate{Row: 1, Col: 3}).Possibilities.Values())
+}
+
+func TestNogoodStoreKeepsOnlyTheCauseOfAFailure(t *testing.T) {
+	sudok := emptyClassicSudoku(t)
+	root, err := rootPencilMark(sudok, selectMostConstrained, propagateConstraints)
+	require.NoError(t, err)
+	store := newNogoodStore(root.(*pencilmarkCandidate), 1)
+
+	irrelevant := assignment{coordinate: sudoku.Coordinate{Row: 1, Col: 1}, value: 1}
+	first := assignment{coordinate: sudoku.Coordinate{Row: 9, Col: 1}, value: 2}
+	second := assignment{coordinate: sudoku.Coordinate{Row: 9, Col: 2}, value: 2}
+	store.learnFailure([]assignment{irrelevant, first, second})
+	assert.Equal(t, [][]assignment{{first, second}}, store.nogoods)
+
+	// the store is full, so the first nogood is replaced
+	third := assignment{coordinate: sudoku.Coordinate{Row: 8, Col: 1}, value: 2}
+	store.learnFailure([]assignment{first, third})
+	assert.Equal(t, [][]assignment{{first, third}}, store.nogoods)
+}
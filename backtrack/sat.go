@@ -0,0 +1,46 @@
+package backtrack
+
+import (
+	"context"
+	"fmt"
+	"sudoku-solver/sat"
+	"sudoku-solver/sudoku"
+)
+
+// satSearch encodes the sudoku as CNF and lets a CDCL solver find the
+// solutions. After every solution a clause that forbids it is added, so the
+// next call to the solver finds a different one.
+type satSearch struct {
+	encoding *sat.Encoding
+	solver   *sat.Solver
+}
+
+var _ engine = (*satSearch)(nil)
+
+func newSATSearch(sudok sudoku.Sudoku) (*satSearch, error) {
+	encoding, err := sat.Encode(sudok)
+	if err != nil {
+		return nil, fmt.Errorf("encode sudoku: %w", err)
+	}
+	return &satSearch{
+		encoding: encoding,
+		solver:   sat.NewSolver(encoding.CNF),
+	}, nil
+}
+
+func (s *satSearch) search(ctx context.Context, found func(sudoku.Solution) bool) {
+	for ctx.Err() == nil {
+		satisfiable, err := s.solver.Solve(ctx)
+		// Solve only fails if the context is cancelled, which ends the search
+		// like an exhausted one. The caller sees the cancellation on the
+		// context.
+		if err != nil || !satisfiable {
+			return
+		}
+		solution := s.encoding.Decode(s.solver.Value)
+		if !found(solution) {
+			return
+		}
+		s.solver.AddClause(s.encoding.Blocking(solution)...)
+	}
+}
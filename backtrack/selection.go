@@ -0,0 +1,61 @@
+package backtrack
+
+import "sudoku-solver/sudoku"
+
+// cellSelection picks the coordinate a pencilmark candidate branches on next.
+// It returns false if all coordinates are filled in.
+type cellSelection func(c *pencilmarkCandidate) (sudoku.Coordinate, bool)
+
+// selectInOrder picks the first empty coordinate in the order of the
+// coordinates of the sudoku.
+func selectInOrder(c *pencilmarkCandidate) (sudoku.Coordinate, bool) {
+	for ; c.coordinateIndex < len(c.sudok.Coordinates); c.coordinateIndex++ {
+		coord := c.sudok.Coordinates[c.coordinateIndex]
+		if !c.cellsState.At(coord).HasValue {
+			return coord, true
+		}
+	}
+	return sudoku.Coordinate{}, false
+}
+
+// selectMostConstrained picks the empty coordinate with the fewest
+// possibilities left (minimum remaining values). Ties are broken by the
+// number of constraints that still connect the coordinate to other empty
+// coordinates, and then by the order of the coordinates of the sudoku.
+func selectMostConstrained(c *pencilmarkCandidate) (sudoku.Coordinate, bool) {
+	best := sudoku.Coordinate{}
+	found := false
+	bestRemaining, bestDegree := 0, 0
+	for _, coord := range c.sudok.Coordinates {
+		cell := c.cellsState.At(coord)
+		if cell.HasValue {
+			continue
+		}
+		remaining := cell.Possibilities.Len()
+		if found && remaining > bestRemaining {
+			continue
+		}
+		degree := c.degree(coord)
+		if found && remaining == bestRemaining && degree <= bestDegree {
+			continue
+		}
+		best, found = coord, true
+		bestRemaining, bestDegree = remaining, degree
+	}
+	return best, found
+}
+
+// degree returns the number of constraints on the coordinate that also
+// constrain at least one other empty coordinate.
+func (c *pencilmarkCandidate) degree(coord sudoku.Coordinate) int {
+	degree := 0
+	for _, constr := range c.index.ConstraintsOf(coord) {
+		for _, other := range constr.ConstrainedCoordinates() {
+			if other != coord && !c.cellsState.At(other).HasValue {
+				degree++
+				break
+			}
+		}
+	}
+	return degree
+}
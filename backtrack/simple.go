@@ -10,6 +10,9 @@ type simpleCandidate struct {
 	cellsState
 	sudok           sudoku.Sudoku
 	coordinateOrder []sudoku.Coordinate
+	// filled holds the coordinates that were filled in since the parent
+	// candidate. It is nil for the root, which has no parent.
+	filled []sudoku.Coordinate
 
 	coordinateIndex int
 }
@@ -17,15 +20,16 @@ type simpleCandidate struct {
 var _ Candidate = (*simpleCandidate)(nil)
 
 func rootSimple(sudok sudoku.Sudoku) (Candidate, error) {
+	grid, err := newCellGrid(sudok)
+	if err != nil {
+		return nil, fmt.Errorf("create grid: %w", err)
+	}
 	candidate := &simpleCandidate{
-		cellsState:      make(cellsState, len(sudok.Coordinates)),
+		cellsState:      newCellsState(grid, sudok.PossibleValues),
 		sudok:           sudok,
 		coordinateOrder: sudok.Coordinates,
 		coordinateIndex: 0,
 	}
-	for _, coor := range sudok.Coordinates {
-		candidate.cellsState[coor] = VariableCellState(sudok.PossibleValues)
-	}
 
 	// as a simple proof of concept, we will fill in all fixed values
 	for _, constr := range sudok.Constraints {
@@ -34,7 +38,7 @@ func rootSimple(sudok sudoku.Sudoku) (Candidate, error) {
 			continue
 		}
 
-		candidate.cellsState[fvc.Coordinate] = FixedCellState(fvc.Value)
+		candidate.cellsState.Set(fvc.Coordinate, FixedCellState(fvc.Value))
 	}
 
 	// now we will remove all values that are not possible for each coordinate
@@ -45,7 +49,7 @@ func rootSimple(sudok sudoku.Sudoku) (Candidate, error) {
 		}
 		fixedValues := make([]int, 0)
 		for _, coor := range nrc.ConstrainedCoordinates() {
-			coorState := candidate.cellsState[coor]
+			coorState := candidate.cellsState.At(coor)
 			if coorState.HasValue {
 				fixedValues = append(fixedValues, coorState.Value)
 			}
@@ -55,13 +59,13 @@ func rootSimple(sudok sudoku.Sudoku) (Candidate, error) {
 			continue
 		}
 		for _, coor := range nrc.ConstrainedCoordinates() {
-			coorState := candidate.cellsState[coor]
+			coorState := candidate.cellsState.At(coor)
 			updated, ok := coorState.WithRemovedPossibilities(fixedValues...)
 			if !ok {
 				// this coordinate is no longer solvable
-				return nil, fmt.Errorf("coordinate %v is no longer solvable", coor)
+				return nil, fmt.Errorf("coordinate %v is no longer solvable: %w", coor, errNoSolution)
 			}
-			candidate.cellsState[coor] = updated
+			candidate.cellsState.Set(coor, updated)
 		}
 	}
 
@@ -69,12 +73,12 @@ func rootSimple(sudok sudoku.Sudoku) (Candidate, error) {
 }
 
 func (c *simpleCandidate) NextCandidates() []Candidate {
-	// if this candidate belongs to the last coordinate then we are done
-	if c.coordinateIndex >= len(c.coordinateOrder)-1 {
+	// if all coordinates were visited then we are done
+	if c.coordinateIndex >= len(c.coordinateOrder) {
 		return nil
 	}
 	coord := c.coordinateOrder[c.coordinateIndex]
-	cell, ok := c.cellsState[coord]
+	cell, ok := c.cellsState.Lookup(coord)
 	if !ok {
 		// this should never happen
 		// TODO: use slog here
@@ -86,25 +90,27 @@ func (c *simpleCandidate) NextCandidates() []Candidate {
 			cellsState:      c.cellsState.Copy(),
 			sudok:           c.sudok,
 			coordinateOrder: c.coordinateOrder,
+			filled:          []sudoku.Coordinate{},
 			coordinateIndex: c.coordinateIndex + 1,
 		}}
 	}
-	currentPossibilities := cell.Possibilities
+	currentPossibilities := cell.Possibilities.Values()
 	nextCandidates := make([]Candidate, 0, len(currentPossibilities))
 	for _, value := range currentPossibilities {
 		// try to fill in the coordinate with the value
 		newState := c.cellsState.Copy()
-		newState[coord] = cellState{
-			HasValue:      true,
-			Value:         value,
-			Possibilities: nil,
-		}
+		newState.Set(coord, FixedCellState(value))
 		nextCandidates = append(nextCandidates, &simpleCandidate{
 			cellsState:      newState,
 			sudok:           c.sudok,
 			coordinateOrder: c.coordinateOrder,
+			filled:          []sudoku.Coordinate{coord},
 			coordinateIndex: c.coordinateIndex + 1,
 		})
 	}
 	return nextCandidates
 }
+
+func (c *simpleCandidate) filledIn() ([]sudoku.Coordinate, bool) {
+	return c.filled, c.filled != nil
+}
@@ -0,0 +1,69 @@
+package backtrack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+// SearchStats describes the tree of candidates a mode searches to find all
+// solutions of a sudoku.
+type SearchStats struct {
+	// Candidates is the number of candidates that were visited.
+	Candidates int
+	// Guesses is the number of candidates that had more than one next
+	// candidate, i.e. where the search had to guess.
+	Guesses int
+	// Solutions is the number of solutions that were found.
+	Solutions int
+}
+
+// MeasureSearch searches all solutions of the sudoku and counts the
+// candidates and guesses it takes. Only modes that search candidates can be
+// measured. It returns the error of the context if the context is cancelled
+// before the search finished.
+func MeasureSearch(ctx context.Context, mode Mode, sudok sudoku.Sudoku) (SearchStats, error) {
+	e, err := mode.engine(sudok)
+	if err != nil && !errors.Is(err, errNoSolution) {
+		return SearchStats{}, err
+	}
+	if e != nil {
+		return SearchStats{}, fmt.Errorf("mode %s does not search candidates", mode)
+	}
+	root, err := mode.rootCandidate(sudok)
+	if errors.Is(err, errNoSolution) {
+		return SearchStats{}, nil
+	}
+	if err != nil {
+		return SearchStats{}, err
+	}
+	var stats SearchStats
+	checker := newViolationChecker(sudok)
+	var measure func(candidate Candidate)
+	measure = func(candidate Candidate) {
+		if ctx.Err() != nil {
+			return
+		}
+		stats.Candidates++
+		if checker.isViolated(candidate) {
+			return
+		}
+		if checker.isSolved(candidate) {
+			stats.Solutions++
+			return
+		}
+		next := candidate.NextCandidates()
+		if len(next) > 1 {
+			stats.Guesses++
+		}
+		for _, nextCandidate := range next {
+			measure(nextCandidate)
+		}
+	}
+	measure(root)
+	if err := ctx.Err(); err != nil {
+		return SearchStats{}, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,82 @@
+package backtrack
+
+import (
+	"context"
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+// trailSearch runs the pencilmark search on a single state instead of copying
+// the state for every candidate. Every change to the state is recorded on a
+// trail and undone when the search backtracks, so a search does not allocate
+// new states. The propagation is the same as in ModePencilMark, which stays
+// as the reference implementation.
+type trailSearch struct {
+	candidate *pencilmarkCandidate
+	checker   violationChecker
+	trail     []cellChange
+}
+
+var _ engine = (*trailSearch)(nil)
+
+func newTrailSearch(sudok sudoku.Sudoku) (*trailSearch, error) {
+	root, err := rootPencilMark(sudok, selectMostConstrained, propagateConstraints)
+	if err != nil {
+		return nil, fmt.Errorf("create root: %w", err)
+	}
+	t := &trailSearch{
+		candidate: root.(*pencilmarkCandidate),
+		checker:   newViolationChecker(sudok),
+		trail:     make([]cellChange, 0, len(sudok.Coordinates)),
+	}
+	t.candidate.cellsState.trail = &t.trail
+	return t, nil
+}
+
+func (t *trailSearch) search(ctx context.Context, found func(sudoku.Solution) bool) {
+	if t.checker.isViolated(t.candidate) {
+		return
+	}
+	t.searchFrom(ctx, found)
+}
+
+// searchFrom searches all solutions reachable from the current state, which
+// must not violate any constraint, and leaves the state as it found it. It
+// returns false if the search should stop.
+func (t *trailSearch) searchFrom(ctx context.Context, found func(sudoku.Solution) bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	c := t.candidate
+	if t.checker.isSolved(c) {
+		return found(c)
+	}
+	coord, ok := c.selectCell(c)
+	if !ok {
+		return true
+	}
+	for _, value := range c.cellsState.At(coord).Possibilities.Values() {
+		mark := len(t.trail)
+		more := true
+		if err := c.FillIn(coord, value); err == nil && !t.checker.index.IsViolatedAt(c, t.filledSince(mark)...) {
+			more = t.searchFrom(ctx, found)
+		}
+		c.cellsState.undo(mark)
+		if !more {
+			return false
+		}
+	}
+	return true
+}
+
+// filledSince returns the coordinates that got a value after the trail had
+// the given length.
+func (t *trailSearch) filledSince(length int) []sudoku.Coordinate {
+	filled := make([]sudoku.Coordinate, 0)
+	for _, change := range t.trail[length:] {
+		if !change.previous.HasValue && t.candidate.cells[change.index].HasValue {
+			filled = append(filled, t.candidate.grid.coordinates[change.index])
+		}
+	}
+	return filled
+}
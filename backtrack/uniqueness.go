@@ -0,0 +1,64 @@
+package backtrack
+
+import (
+	"context"
+	"sudoku-solver/sudoku"
+)
+
+// Uniqueness tells whether a sudoku has no, one or multiple solutions.
+type Uniqueness struct {
+	// Solutions holds no solution, the unique solution or two distinct
+	// solutions if there are multiple.
+	Solutions []sudoku.Solution
+	// Differences holds the coordinates where the two solutions differ, in
+	// the order of the coordinates of the sudoku. It is empty unless there
+	// are multiple solutions. For sudokus with unknown regions it can be empty
+	// for multiple solutions, because the solutions only keep their values.
+	Differences []sudoku.Coordinate
+}
+
+// HasSolution returns true if the sudoku has at least one solution.
+func (u Uniqueness) HasSolution() bool {
+	return len(u.Solutions) > 0
+}
+
+// IsUnique returns true if the sudoku has exactly one solution.
+func (u Uniqueness) IsUnique() bool {
+	return len(u.Solutions) == 1
+}
+
+// CheckUniqueness searches up to two solutions of the sudoku. If it finds
+// two, it returns the coordinates where they differ, which are the cells that
+// need another clue. It returns the error of the context if the context is
+// cancelled before the search finished.
+func CheckUniqueness(ctx context.Context, mode Mode, sudok sudoku.Sudoku) (Uniqueness, error) {
+	var uniqueness Uniqueness
+	err := search(ctx, mode, sudok, func(solution sudoku.Solution) bool {
+		uniqueness.Solutions = append(uniqueness.Solutions, snapshotSolution(sudok, solution))
+		return len(uniqueness.Solutions) < 2
+	})
+	if err != nil {
+		return Uniqueness{}, err
+	}
+	if len(uniqueness.Solutions) < 2 && ctx.Err() != nil {
+		return Uniqueness{}, ctx.Err()
+	}
+	if len(uniqueness.Solutions) == 2 {
+		uniqueness.Differences = differences(sudok, uniqueness.Solutions[0], uniqueness.Solutions[1])
+	}
+	return uniqueness, nil
+}
+
+// differences returns the coordinates of the sudoku where the solutions have
+// different values.
+func differences(sudok sudoku.Sudoku, solution1, solution2 sudoku.Solution) []sudoku.Coordinate {
+	var coordinates []sudoku.Coordinate
+	for _, coord := range sudok.Coordinates {
+		value1, ok1 := solution1.Get(coord)
+		value2, ok2 := solution2.Get(coord)
+		if ok1 != ok2 || value1 != value2 {
+			coordinates = append(coordinates, coord)
+		}
+	}
+	return coordinates
+}
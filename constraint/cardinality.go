@@ -0,0 +1,169 @@
+package constraint
+
+import (
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+// CardinalityConstraint is a constraint that requires every value in Counts
+// to appear exactly that many times at the coordinates in Coordinates. Values
+// that are not in Counts can appear any number of times.
+// A NoRepeatConstraint over a full house is a CardinalityConstraint that
+// requires every value exactly once.
+type CardinalityConstraint struct {
+	Coordinates []sudoku.Coordinate
+	Counts      map[int]int
+}
+
+var _ sudoku.Constraint = CardinalityConstraint{}
+
+// IsViolated returns true if a value appears more often than required or if
+// there are not enough empty cells left for the values that are still missing.
+func (c CardinalityConstraint) IsViolated(solution sudoku.Solution) bool {
+	occurrences := make(map[int]int, len(c.Counts))
+	empty := 0
+	for _, coord := range c.Coordinates {
+		value, ok := solution.Get(coord)
+		if !ok {
+			empty++
+			continue
+		}
+		occurrences[value]++
+	}
+	missing := 0
+	for value, count := range c.Counts {
+		if occurrences[value] > count {
+			return true
+		}
+		missing += count - occurrences[value]
+	}
+	return missing > empty
+}
+
+func (c CardinalityConstraint) ConstrainedCoordinates() []sudoku.Coordinate {
+	return c.Coordinates
+}
+
+var _ sudoku.Propagator = CardinalityConstraint{}
+
+// Propagate compares for every counted value how often it was placed and in
+// how many cells it is still possible. If the value was placed often enough
+// it is removed from all other cells. If it needs all cells it is still
+// possible in, these cells are reduced to this value.
+func (c CardinalityConstraint) Propagate(domains sudoku.Domains) error {
+	for value, count := range c.Counts {
+		placed := 0
+		possibleIn := make([]sudoku.Coordinate, 0)
+		for _, coord := range c.Coordinates {
+			if placedValue, ok := domains.Get(coord); ok {
+				if placedValue == value {
+					placed++
+				}
+				continue
+			}
+			if domains.CanBe(coord, value) {
+				possibleIn = append(possibleIn, coord)
+			}
+		}
+		var keep func(int) bool
+		switch {
+		case placed > count:
+			return fmt.Errorf("value %d appears more than %d times", value, count)
+		case placed+len(possibleIn) < count:
+			return fmt.Errorf("value %d can't appear %d times anymore", value, count)
+		case placed == count:
+			keep = func(v int) bool { return v != value }
+		case placed+len(possibleIn) == count:
+			keep = func(v int) bool { return v == value }
+		default:
+			continue
+		}
+		for _, coord := range possibleIn {
+			if err := domains.Restrict(coord, keep); err != nil {
+				return fmt.Errorf("value %d at %v: %w", value, coord, err)
+			}
+		}
+	}
+	return nil
+}
+
+var _ sudoku.Supporter = CardinalityConstraint{}
+
+// Supported returns the candidates of the coordinate that keep every counted
+// value within reach of its count: no counted value appears too often, every
+// counted value can still be placed often enough in the other cells and the
+// missing values fit into the other empty cells. The counts are not matched
+// against each other, so a value can be returned without support.
+func (c CardinalityConstraint) Supported(domains sudoku.Domains, coordinate sudoku.Coordinate) []int {
+	placed := make(map[int]int, len(c.Counts))
+	possible := make(map[int]int, len(c.Counts))
+	empty := 0
+	for _, coord := range c.Coordinates {
+		if coord == coordinate {
+			continue
+		}
+		if value, ok := domains.Get(coord); ok {
+			placed[value]++
+			continue
+		}
+		empty++
+		for value := range c.Counts {
+			if domains.CanBe(coord, value) {
+				possible[value]++
+			}
+		}
+	}
+	candidates := domains.Candidates(coordinate)
+	supported := make([]int, 0, len(candidates))
+	for _, candidate := range candidates {
+		missing := 0
+		fits := true
+		for value, count := range c.Counts {
+			occurrences := placed[value]
+			if value == candidate {
+				occurrences++
+			}
+			if occurrences > count || occurrences+possible[value] < count {
+				fits = false
+				break
+			}
+			missing += count - occurrences
+		}
+		if fits && missing <= empty {
+			supported = append(supported, candidate)
+		}
+	}
+	return supported
+}
+
+// NewCardinalityConstraint creates a new CardinalityConstraint and checks that
+// the counts can fit into the coordinates.
+func NewCardinalityConstraint(coordinates []sudoku.Coordinate, counts map[int]int) (*CardinalityConstraint, error) {
+	if len(coordinates) == 0 {
+		return nil, fmt.Errorf("cardinality constraint needs at least one coordinate")
+	}
+	total := 0
+	for value, count := range counts {
+		if count < 0 {
+			return nil, fmt.Errorf("count %d for value %d is negative", count, value)
+		}
+		total += count
+	}
+	if total > len(coordinates) {
+		return nil, fmt.Errorf("counts add up to %d but there are only %d coordinates", total, len(coordinates))
+	}
+	return &CardinalityConstraint{
+		Coordinates: coordinates,
+		Counts:      counts,
+	}, nil
+}
+
+// NewEachValueCardinalityConstraint creates a new CardinalityConstraint that
+// requires each of the given values to appear exactly count times.
+func NewEachValueCardinalityConstraint(coordinates []sudoku.Coordinate, values []int, count int) (*CardinalityConstraint, error) {
+	counts := make(map[int]int, len(values))
+	for _, value := range values {
+		counts[value] = count
+	}
+	return NewCardinalityConstraint(coordinates, counts)
+}
@@ -0,0 +1,46 @@
+package constraint_test
+
+import (
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinalityConstraint(t *testing.T) {
+	coordinates := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 1, Col: 3}, {Row: 1, Col: 4}}
+	c, err := constraint.NewCardinalityConstraint(coordinates, map[int]int{5: 2, 1: 1})
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		values   []int
+		violated bool
+	}{
+		{"Empty", []int{0, 0, 0, 0}, false},
+		{"Complete", []int{5, 1, 5, 3}, false},
+		{"TooMany", []int{5, 5, 5, 0}, true},
+		{"NotEnoughSpace", []int{2, 3, 5, 0}, true},
+		{"MissingAtTheEnd", []int{5, 2, 3, 1}, true},
+		{"UncountedValues", []int{7, 7, 0, 0}, true},
+		{"StillPossible", []int{7, 0, 0, 0}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values := make(map[sudoku.Coordinate]int)
+			for i, value := range test.values {
+				if value != 0 {
+					values[coordinates[i]] = value
+				}
+			}
+			assert.Equal(t, test.violated, c.IsViolated(sudoku.MapSolution(values)))
+		})
+	}
+}
+
+func TestNewCardinalityConstraintTooManyValues(t *testing.T) {
+	coordinates := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}}
+	_, err := constraint.NewEachValueCardinalityConstraint(coordinates, []int{1, 2}, 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,365 @@
+package constraint
+
+import (
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+// ExpressionConstraint is a constraint that is given as a boolean expression
+// over the values of named cells, e.g. "R1C1 + R1C2 == 2 * R3C3". The
+// expression is only evaluated once all cells it references are filled in.
+//
+// Expressions support integer literals, cell references (R1C1 or C1R1), the
+// arithmetic operators + - * / %, the comparisons == != < <= > >=, the boolean
+// operators && || ! and the functions abs, min and max.
+//
+// The expression is parsed once by NewExpressionConstraint, which is the only
+// way to create the constraint.
+type ExpressionConstraint struct {
+	expression  string
+	root        expressionNode
+	coordinates []sudoku.Coordinate
+}
+
+var _ sudoku.Constraint = ExpressionConstraint{}
+
+// NewExpressionConstraint parses the given expression into a constraint.
+// The expression has to evaluate to a boolean.
+func NewExpressionConstraint(expression string) (*ExpressionConstraint, error) {
+	root, err := parseExpression(expression)
+	if err != nil {
+		return nil, fmt.Errorf("parse expression %q: %w", expression, err)
+	}
+	if root.kind() != kindBool {
+		return nil, fmt.Errorf("expression %q is not a condition", expression)
+	}
+	coordinates := make([]sudoku.Coordinate, 0)
+	root.collectCoordinates(func(coordinate sudoku.Coordinate) {
+		for _, c := range coordinates {
+			if c == coordinate {
+				return
+			}
+		}
+		coordinates = append(coordinates, coordinate)
+	})
+	if len(coordinates) == 0 {
+		return nil, fmt.Errorf("expression %q does not reference any cell", expression)
+	}
+	return &ExpressionConstraint{
+		expression:  expression,
+		root:        root,
+		coordinates: coordinates,
+	}, nil
+}
+
+// IsViolated returns true if all referenced cells are filled in and the
+// expression is false or can't be evaluated (e.g. because of a division by
+// zero).
+func (c ExpressionConstraint) IsViolated(solution sudoku.Solution) bool {
+	for _, coord := range c.coordinates {
+		if _, ok := solution.Get(coord); !ok {
+			return false
+		}
+	}
+	result, err := c.root.eval(solution)
+	if err != nil {
+		return true
+	}
+	return result == 0
+}
+
+func (c ExpressionConstraint) ConstrainedCoordinates() []sudoku.Coordinate {
+	return c.coordinates
+}
+
+var _ sudoku.Propagator = ExpressionConstraint{}
+
+// Propagate removes the values from the last empty cell of the expression
+// that would make the expression false. As long as more than one cell is
+// empty nothing is removed.
+func (c ExpressionConstraint) Propagate(domains sudoku.Domains) error {
+	var empty sudoku.Coordinate
+	emptyCount := 0
+	for _, coord := range c.coordinates {
+		if _, ok := domains.Get(coord); !ok {
+			empty = coord
+			emptyCount++
+		}
+	}
+	if emptyCount != 1 {
+		return nil
+	}
+	err := domains.Restrict(empty, func(value int) bool {
+		result, err := c.root.eval(withValues{Solution: domains, values: map[sudoku.Coordinate]int{empty: value}})
+		return err == nil && result != 0
+	})
+	if err != nil {
+		return fmt.Errorf("no value at %v satisfies %q: %w", empty, c.expression, err)
+	}
+	return nil
+}
+
+// maxSupportSearch is the maximum number of other empty cells for which
+// Supported searches their values.
+const maxSupportSearch = 3
+
+var _ sudoku.Supporter = ExpressionConstraint{}
+
+// Supported returns the candidates of the coordinate for which the other empty
+// cells of the expression have candidates that make it true. The candidates
+// of the other cells are searched, so this is only done as long as at most
+// maxSupportSearch of them are empty. Otherwise all candidates are returned.
+func (c ExpressionConstraint) Supported(domains sudoku.Domains, coordinate sudoku.Coordinate) []int {
+	others := make([]sudoku.Coordinate, 0)
+	for _, coord := range c.coordinates {
+		if _, ok := domains.Get(coord); !ok && coord != coordinate {
+			others = append(others, coord)
+		}
+	}
+	candidates := domains.Candidates(coordinate)
+	if len(others) > maxSupportSearch {
+		return candidates
+	}
+	solution := withValues{Solution: domains, values: make(map[sudoku.Coordinate]int, len(others)+1)}
+	var search func(i int) bool
+	search = func(i int) bool {
+		if i == len(others) {
+			result, err := c.root.eval(solution)
+			return err == nil && result != 0
+		}
+		for _, value := range domains.Candidates(others[i]) {
+			solution.values[others[i]] = value
+			if search(i + 1) {
+				return true
+			}
+		}
+		return false
+	}
+	supported := make([]int, 0, len(candidates))
+	for _, value := range candidates {
+		solution.values[coordinate] = value
+		if search(0) {
+			supported = append(supported, value)
+		}
+	}
+	return supported
+}
+
+// withValues is a solution that has additional values at some coordinates.
+type withValues struct {
+	sudoku.Solution
+	values map[sudoku.Coordinate]int
+}
+
+func (s withValues) Get(coordinate sudoku.Coordinate) (int, bool) {
+	if value, ok := s.values[coordinate]; ok {
+		return value, true
+	}
+	return s.Solution.Get(coordinate)
+}
+
+func (c ExpressionConstraint) String() string {
+	return c.expression
+}
+
+type expressionKind int
+
+const (
+	kindInt expressionKind = iota
+	kindBool
+)
+
+func (k expressionKind) String() string {
+	if k == kindBool {
+		return "condition"
+	}
+	return "number"
+}
+
+// expressionNode is a node in the syntax tree of an expression. Booleans are
+// evaluated to 1 (true) and 0 (false).
+type expressionNode interface {
+	kind() expressionKind
+	eval(sudoku.Solution) (int, error)
+	collectCoordinates(func(sudoku.Coordinate))
+}
+
+type literalNode struct {
+	value int
+}
+
+func (n literalNode) kind() expressionKind { return kindInt }
+
+func (n literalNode) eval(sudoku.Solution) (int, error) { return n.value, nil }
+
+func (n literalNode) collectCoordinates(func(sudoku.Coordinate)) {}
+
+type cellNode struct {
+	coordinate sudoku.Coordinate
+}
+
+func (n cellNode) kind() expressionKind { return kindInt }
+
+func (n cellNode) eval(solution sudoku.Solution) (int, error) {
+	value, ok := solution.Get(n.coordinate)
+	if !ok {
+		return 0, fmt.Errorf("cell %v is not filled in", n.coordinate)
+	}
+	return value, nil
+}
+
+func (n cellNode) collectCoordinates(f func(sudoku.Coordinate)) { f(n.coordinate) }
+
+type unaryNode struct {
+	operator string
+	operand  expressionNode
+}
+
+func (n unaryNode) kind() expressionKind {
+	if n.operator == "!" {
+		return kindBool
+	}
+	return kindInt
+}
+
+func (n unaryNode) eval(solution sudoku.Solution) (int, error) {
+	value, err := n.operand.eval(solution)
+	if err != nil {
+		return 0, err
+	}
+	switch n.operator {
+	case "-":
+		return -value, nil
+	case "!":
+		return boolToInt(value == 0), nil
+	default:
+		return 0, fmt.Errorf("unknown unary operator %s", n.operator)
+	}
+}
+
+func (n unaryNode) collectCoordinates(f func(sudoku.Coordinate)) {
+	n.operand.collectCoordinates(f)
+}
+
+type binaryNode struct {
+	operator    string
+	left, right expressionNode
+}
+
+func (n binaryNode) kind() expressionKind {
+	switch n.operator {
+	case "+", "-", "*", "/", "%":
+		return kindInt
+	default:
+		return kindBool
+	}
+}
+
+func (n binaryNode) eval(solution sudoku.Solution) (int, error) {
+	left, err := n.left.eval(solution)
+	if err != nil {
+		return 0, err
+	}
+	// && and || short circuit like they do in go
+	switch {
+	case n.operator == "&&" && left == 0:
+		return 0, nil
+	case n.operator == "||" && left != 0:
+		return 1, nil
+	}
+	right, err := n.right.eval(solution)
+	if err != nil {
+		return 0, err
+	}
+	switch n.operator {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	case "%":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left % right, nil
+	case "==":
+		return boolToInt(left == right), nil
+	case "!=":
+		return boolToInt(left != right), nil
+	case "<":
+		return boolToInt(left < right), nil
+	case "<=":
+		return boolToInt(left <= right), nil
+	case ">":
+		return boolToInt(left > right), nil
+	case ">=":
+		return boolToInt(left >= right), nil
+	case "&&", "||":
+		return boolToInt(right != 0), nil
+	default:
+		return 0, fmt.Errorf("unknown binary operator %s", n.operator)
+	}
+}
+
+func (n binaryNode) collectCoordinates(f func(sudoku.Coordinate)) {
+	n.left.collectCoordinates(f)
+	n.right.collectCoordinates(f)
+}
+
+type functionNode struct {
+	name      string
+	arguments []expressionNode
+}
+
+func (n functionNode) kind() expressionKind { return kindInt }
+
+func (n functionNode) eval(solution sudoku.Solution) (int, error) {
+	values := make([]int, 0, len(n.arguments))
+	for _, argument := range n.arguments {
+		value, err := argument.eval(solution)
+		if err != nil {
+			return 0, err
+		}
+		values = append(values, value)
+	}
+	switch n.name {
+	case "abs":
+		if values[0] < 0 {
+			return -values[0], nil
+		}
+		return values[0], nil
+	case "min":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = min(result, value)
+		}
+		return result, nil
+	case "max":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = max(result, value)
+		}
+		return result, nil
+	default:
+		return 0, fmt.Errorf("unknown function %s", n.name)
+	}
+}
+
+func (n functionNode) collectCoordinates(f func(sudoku.Coordinate)) {
+	for _, argument := range n.arguments {
+		argument.collectCoordinates(f)
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
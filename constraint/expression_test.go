@@ -0,0 +1,69 @@
+package constraint_test
+
+import (
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpressionConstraint(t *testing.T) {
+	r1c1 := sudoku.Coordinate{Row: 1, Col: 1}
+	r1c2 := sudoku.Coordinate{Row: 1, Col: 2}
+	r3c3 := sudoku.Coordinate{Row: 3, Col: 3}
+	tests := []struct {
+		expression string
+		values     map[sudoku.Coordinate]int
+		violated   bool
+	}{
+		{"R1C1 + R1C2 == 2 * R3C3", map[sudoku.Coordinate]int{r1c1: 3, r1c2: 5, r3c3: 4}, false},
+		{"R1C1 + R1C2 == 2 * R3C3", map[sudoku.Coordinate]int{r1c1: 3, r1c2: 5, r3c3: 3}, true},
+		{"R1C1 + R1C2 == 2 * R3C3", map[sudoku.Coordinate]int{r1c1: 3, r1c2: 5}, false},
+		{"R1C1 % 2 == R1C2 % 2", map[sudoku.Coordinate]int{r1c1: 3, r1c2: 7}, false},
+		{"R1C1 % 2 == R1C2 % 2", map[sudoku.Coordinate]int{r1c1: 3, r1c2: 8}, true},
+		{"abs(R1C1 - c2r1) >= 5", map[sudoku.Coordinate]int{r1c1: 2, r1c2: 7}, false},
+		{"abs(R1C1 - c2r1) >= 5", map[sudoku.Coordinate]int{r1c1: 2, r1c2: 6}, true},
+		{"max(R1C1, R1C2) - min(R1C1, R1C2) == 1 || R1C1 == 9", map[sudoku.Coordinate]int{r1c1: 9, r1c2: 1}, false},
+		{"!(R1C1 < R1C2) && -R1C1 + 10 > 2", map[sudoku.Coordinate]int{r1c1: 7, r1c2: 7}, false},
+		{"!(R1C1 < R1C2) && -R1C1 + 10 > 2", map[sudoku.Coordinate]int{r1c1: 8, r1c2: 7}, true},
+		{"R1C2 / R1C1 == 2", map[sudoku.Coordinate]int{r1c1: 0, r1c2: 7}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			c, err := constraint.NewExpressionConstraint(test.expression)
+			require.NoError(t, err)
+			assert.Equal(t, test.violated, c.IsViolated(sudoku.MapSolution(test.values)))
+		})
+	}
+}
+
+func TestExpressionConstraintCoordinates(t *testing.T) {
+	c, err := constraint.NewExpressionConstraint("R1C1 + R1C2 == 2 * R3C3 - R1C1")
+	require.NoError(t, err)
+	assert.Equal(t, []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 3, Col: 3}}, c.ConstrainedCoordinates())
+}
+
+func TestExpressionConstraintInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"R1C1 + R1C2",
+		"R1C1 = R1C2",
+		"R1C1 == (R1C2",
+		"R1C1 == R1C2)",
+		"R1R1 == 3",
+		"foo(R1C1) == 3",
+		"abs(R1C1, R1C2) == 3",
+		"(R1C1 == 3) + 1 == 2",
+		"!R1C1",
+		"1 + 2 == 3",
+		"R1C1 == 3 == 3",
+	}
+	for _, expression := range invalid {
+		t.Run(expression, func(t *testing.T) {
+			_, err := constraint.NewExpressionConstraint(expression)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package constraint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sudoku-solver/sudoku"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokenNumber tokenType = iota
+	tokenIdentifier
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+	tokenEnd
+)
+
+type token struct {
+	typ      tokenType
+	text     string
+	position int
+}
+
+// twoCharOperators have to be checked before the single character operators,
+// so that "<=" is not read as "<" followed by "=".
+var twoCharOperators = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+const singleCharOperators = "+-*/%<>!"
+
+func tokenizeExpression(input string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{typ: tokenNumber, text: string(runes[start:i]), position: start})
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{typ: tokenIdentifier, text: string(runes[start:i]), position: start})
+		case r == '(':
+			tokens = append(tokens, token{typ: tokenLeftParen, text: "(", position: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{typ: tokenRightParen, text: ")", position: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{typ: tokenComma, text: ",", position: i})
+			i++
+		default:
+			operator := ""
+			if i+1 < len(runes) {
+				for _, candidate := range twoCharOperators {
+					if string(runes[i:i+2]) == candidate {
+						operator = candidate
+						break
+					}
+				}
+			}
+			if operator == "" && strings.ContainsRune(singleCharOperators, r) {
+				operator = string(r)
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i+1)
+			}
+			tokens = append(tokens, token{typ: tokenOperator, text: operator, position: i})
+			i += len([]rune(operator))
+		}
+	}
+	tokens = append(tokens, token{typ: tokenEnd, position: len(runes)})
+	return tokens, nil
+}
+
+// expressionParser is a recursive descent parser for the expressions of an
+// ExpressionConstraint. From lowest to highest precedence the grammar is:
+//
+//	or         = and { "||" and }
+//	and        = not { "&&" not }
+//	not        = "!" not | comparison
+//	comparison = sum [ ("==" | "!=" | "<" | "<=" | ">" | ">=") sum ]
+//	sum        = product { ("+" | "-") product }
+//	product    = unary { ("*" | "/" | "%") unary }
+//	unary      = "-" unary | primary
+//	primary    = number | cell | function "(" or { "," or } ")" | "(" or ")"
+type expressionParser struct {
+	tokens []token
+	pos    int
+}
+
+func parseExpression(input string) (expressionNode, error) {
+	tokens, err := tokenizeExpression(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &expressionParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.typ != tokenEnd {
+		return nil, fmt.Errorf("unexpected %q at position %d", next.text, next.position+1)
+	}
+	return node, nil
+}
+
+func (p *expressionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+// acceptOperator consumes the next token if it is one of the given operators.
+func (p *expressionParser) acceptOperator(operators ...string) (string, bool) {
+	t := p.peek()
+	if t.typ != tokenOperator {
+		return "", false
+	}
+	for _, operator := range operators {
+		if t.text == operator {
+			p.pos++
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+func (p *expressionParser) parseOr() (expressionNode, error) {
+	return p.parseBinary(p.parseAnd, kindBool, "||")
+}
+
+func (p *expressionParser) parseAnd() (expressionNode, error) {
+	return p.parseBinary(p.parseNot, kindBool, "&&")
+}
+
+func (p *expressionParser) parseNot() (expressionNode, error) {
+	if _, ok := p.acceptOperator("!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != kindBool {
+			return nil, fmt.Errorf("operator ! expects a condition, got a number")
+		}
+		return unaryNode{operator: "!", operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *expressionParser) parseComparison() (expressionNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	operator, ok := p.acceptOperator("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != kindInt || right.kind() != kindInt {
+		return nil, fmt.Errorf("operator %s expects numbers on both sides", operator)
+	}
+	return binaryNode{operator: operator, left: left, right: right}, nil
+}
+
+func (p *expressionParser) parseSum() (expressionNode, error) {
+	return p.parseBinary(p.parseProduct, kindInt, "+", "-")
+}
+
+func (p *expressionParser) parseProduct() (expressionNode, error) {
+	return p.parseBinary(p.parseUnary, kindInt, "*", "/", "%")
+}
+
+// parseBinary parses a left associative chain of the given operators whose
+// operands are parsed by operand and have to be of the given kind.
+func (p *expressionParser) parseBinary(operand func() (expressionNode, error), kind expressionKind, operators ...string) (expressionNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.acceptOperator(operators...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kind || right.kind() != kind {
+			return nil, fmt.Errorf("operator %s expects a %s on both sides", operator, kind)
+		}
+		left = binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseUnary() (expressionNode, error) {
+	if _, ok := p.acceptOperator("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != kindInt {
+			return nil, fmt.Errorf("operator - expects a number, got a condition")
+		}
+		return unaryNode{operator: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *expressionParser) parsePrimary() (expressionNode, error) {
+	t := p.next()
+	switch t.typ {
+	case tokenNumber:
+		value, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s: %w", t.text, err)
+		}
+		return literalNode{value: value}, nil
+	case tokenIdentifier:
+		if p.peek().typ == tokenLeftParen {
+			return p.parseFunction(t)
+		}
+		coordinate, err := sudoku.ParseCoordinateString(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cell %s at position %d: %w", t.text, t.position+1, err)
+		}
+		return cellNode{coordinate: coordinate}, nil
+	case tokenLeftParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.typ != tokenRightParen {
+			return nil, fmt.Errorf("expected ) at position %d", closing.position+1)
+		}
+		return node, nil
+	case tokenEnd:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.position+1)
+	}
+}
+
+func (p *expressionParser) parseFunction(name token) (expressionNode, error) {
+	function := strings.ToLower(name.text)
+	minArguments, maxArguments := 0, 0
+	switch function {
+	case "abs":
+		minArguments, maxArguments = 1, 1
+	case "min", "max":
+		minArguments, maxArguments = 1, -1
+	default:
+		return nil, fmt.Errorf("unknown function %s at position %d", name.text, name.position+1)
+	}
+	// consume the opening parenthesis
+	p.next()
+	arguments := make([]expressionNode, 0)
+	if p.peek().typ != tokenRightParen {
+		for {
+			argument, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if argument.kind() != kindInt {
+				return nil, fmt.Errorf("function %s expects numbers as arguments", function)
+			}
+			arguments = append(arguments, argument)
+			if p.peek().typ != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.typ != tokenRightParen {
+		return nil, fmt.Errorf("expected ) at position %d", closing.position+1)
+	}
+	if len(arguments) < minArguments || (maxArguments >= 0 && len(arguments) > maxArguments) {
+		return nil, fmt.Errorf("function %s called with %d arguments", function, len(arguments))
+	}
+	return functionNode{name: function, arguments: arguments}, nil
+}
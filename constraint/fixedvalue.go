@@ -20,3 +20,20 @@ func (c FixedValueConstraint) IsViolated(solution sudoku.Solution) bool {
 func (c FixedValueConstraint) ConstrainedCoordinates() []sudoku.Coordinate {
 	return []sudoku.Coordinate{c.Coordinate}
 }
+
+var _ sudoku.Propagator = FixedValueConstraint{}
+
+func (c FixedValueConstraint) Propagate(domains sudoku.Domains) error {
+	return domains.Restrict(c.Coordinate, func(value int) bool {
+		return value == c.Value
+	})
+}
+
+var _ sudoku.Supporter = FixedValueConstraint{}
+
+func (c FixedValueConstraint) Supported(domains sudoku.Domains, coordinate sudoku.Coordinate) []int {
+	if coordinate != c.Coordinate || !domains.CanBe(coordinate, c.Value) {
+		return nil
+	}
+	return []int{c.Value}
+}
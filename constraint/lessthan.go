@@ -0,0 +1,145 @@
+package constraint
+
+import (
+	"fmt"
+	"slices"
+	"sudoku-solver/sudoku"
+)
+
+// LessThanConstraint is a constraint that requires that the value at Smaller
+// is less than the value at Bigger.
+type LessThanConstraint struct {
+	Smaller sudoku.Coordinate
+	Bigger  sudoku.Coordinate
+}
+
+var _ sudoku.Constraint = LessThanConstraint{}
+
+func (c LessThanConstraint) IsViolated(solution sudoku.Solution) bool {
+	smaller, ok := solution.Get(c.Smaller)
+	if !ok {
+		return false
+	}
+	bigger, ok := solution.Get(c.Bigger)
+	if !ok {
+		return false
+	}
+	return smaller >= bigger
+}
+
+func (c LessThanConstraint) ConstrainedCoordinates() []sudoku.Coordinate {
+	return []sudoku.Coordinate{c.Smaller, c.Bigger}
+}
+
+var _ sudoku.Propagator = LessThanConstraint{}
+
+// Propagate removes all values from the smaller cell that are not below the
+// biggest value of the bigger cell and vice versa.
+func (c LessThanConstraint) Propagate(domains sudoku.Domains) error {
+	_, biggerMax := domains.Bounds(c.Bigger)
+	err := domains.Restrict(c.Smaller, func(value int) bool {
+		return value < biggerMax
+	})
+	if err != nil {
+		return fmt.Errorf("coordinate %v has no value below %d left: %w", c.Smaller, biggerMax, err)
+	}
+	smallerMin, _ := domains.Bounds(c.Smaller)
+	err = domains.Restrict(c.Bigger, func(value int) bool {
+		return value > smallerMin
+	})
+	if err != nil {
+		return fmt.Errorf("coordinate %v has no value above %d left: %w", c.Bigger, smallerMin, err)
+	}
+	return nil
+}
+
+var _ sudoku.Supporter = LessThanConstraint{}
+
+func (c LessThanConstraint) Supported(domains sudoku.Domains, coordinate sudoku.Coordinate) []int {
+	smallerMin, _ := domains.Bounds(c.Smaller)
+	_, biggerMax := domains.Bounds(c.Bigger)
+	supported := make([]int, 0)
+	for _, value := range domains.Candidates(coordinate) {
+		if coordinate == c.Smaller && value >= biggerMax {
+			continue
+		}
+		if coordinate == c.Bigger && value <= smallerMin {
+			continue
+		}
+		supported = append(supported, value)
+	}
+	return supported
+}
+
+// NewMinimumConstraints creates the constraints that require the value at cell
+// to be less than the values of all its orthogonal neighbours in coordinates.
+func NewMinimumConstraints(cell sudoku.Coordinate, coordinates []sudoku.Coordinate) ([]LessThanConstraint, error) {
+	neighbours, err := orthogonalNeighbours(cell, coordinates)
+	if err != nil {
+		return nil, fmt.Errorf("minimum cell: %w", err)
+	}
+	constraints := make([]LessThanConstraint, 0, len(neighbours))
+	for _, neighbour := range neighbours {
+		constraints = append(constraints, LessThanConstraint{Smaller: cell, Bigger: neighbour})
+	}
+	return constraints, nil
+}
+
+// NewMaximumConstraints creates the constraints that require the value at cell
+// to be greater than the values of all its orthogonal neighbours in coordinates.
+func NewMaximumConstraints(cell sudoku.Coordinate, coordinates []sudoku.Coordinate) ([]LessThanConstraint, error) {
+	neighbours, err := orthogonalNeighbours(cell, coordinates)
+	if err != nil {
+		return nil, fmt.Errorf("maximum cell: %w", err)
+	}
+	constraints := make([]LessThanConstraint, 0, len(neighbours))
+	for _, neighbour := range neighbours {
+		constraints = append(constraints, LessThanConstraint{Smaller: neighbour, Bigger: cell})
+	}
+	return constraints, nil
+}
+
+func orthogonalNeighbours(cell sudoku.Coordinate, coordinates []sudoku.Coordinate) ([]sudoku.Coordinate, error) {
+	if !slices.Contains(coordinates, cell) {
+		return nil, fmt.Errorf("coordinate %v is not in the sudoku", cell)
+	}
+	neighbours := make([]sudoku.Coordinate, 0, 4)
+	for _, neighbour := range cell.OrthogonalNeighbours() {
+		if slices.Contains(coordinates, neighbour) {
+			neighbours = append(neighbours, neighbour)
+		}
+	}
+	if len(neighbours) == 0 {
+		return nil, fmt.Errorf("coordinate %v has no orthogonal neighbours", cell)
+	}
+	return neighbours, nil
+}
+
+// NewThermometerConstraints creates the constraints of a thermometer: the
+// values increase from the bulb at the first coordinate to the last
+// coordinate. Consecutive coordinates have to touch orthogonally or
+// diagonally.
+func NewThermometerConstraints(coordinates []sudoku.Coordinate) ([]LessThanConstraint, error) {
+	if len(coordinates) < 2 {
+		return nil, fmt.Errorf("thermometer needs at least two coordinates, got %d", len(coordinates))
+	}
+	constraints := make([]LessThanConstraint, 0, len(coordinates)-1)
+	for i := 1; i < len(coordinates); i++ {
+		smaller, bigger := coordinates[i-1], coordinates[i]
+		if slices.Contains(coordinates[:i], bigger) {
+			return nil, fmt.Errorf("thermometer contains %v twice", bigger)
+		}
+		if abs(smaller.Row-bigger.Row) > 1 || abs(smaller.Col-bigger.Col) > 1 {
+			return nil, fmt.Errorf("thermometer coordinates %v and %v don't touch", smaller, bigger)
+		}
+		constraints = append(constraints, LessThanConstraint{Smaller: smaller, Bigger: bigger})
+	}
+	return constraints, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
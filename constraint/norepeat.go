@@ -3,6 +3,7 @@ package constraint
 import (
 	"fmt"
 	"math"
+	"slices"
 	"sudoku-solver/sudoku"
 )
 
@@ -31,6 +32,91 @@ func (c NoRepeatConstraint) ConstrainedCoordinates() []sudoku.Coordinate {
 	return c.Coordinates
 }
 
+var _ sudoku.Propagator = NoRepeatConstraint{}
+
+// Propagate removes the values of the filled in coordinates from all other
+// coordinates.
+func (c NoRepeatConstraint) Propagate(domains sudoku.Domains) error {
+	placed := make([]int, 0, len(c.Coordinates))
+	for _, coord := range c.Coordinates {
+		if value, ok := domains.Get(coord); ok {
+			if slices.Contains(placed, value) {
+				return fmt.Errorf("value %d appears more than once", value)
+			}
+			placed = append(placed, value)
+		}
+	}
+	if len(placed) == 0 {
+		return nil
+	}
+	for _, coord := range c.Coordinates {
+		if _, ok := domains.Get(coord); ok {
+			continue
+		}
+		err := domains.Restrict(coord, func(value int) bool {
+			return !slices.Contains(placed, value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ sudoku.Supporter = NoRepeatConstraint{}
+
+// Supported returns the values of the coordinate for which all other
+// coordinates can still get different values. This is checked by matching the
+// other coordinates to the values with augmenting paths.
+func (c NoRepeatConstraint) Supported(domains sudoku.Domains, coordinate sudoku.Coordinate) []int {
+	others := make([]sudoku.Coordinate, 0, len(c.Coordinates))
+	for _, coord := range c.Coordinates {
+		if coord != coordinate {
+			others = append(others, coord)
+		}
+	}
+	candidates := make([][]int, len(others))
+	for i, coord := range others {
+		candidates[i] = domains.Candidates(coord)
+	}
+	supported := make([]int, 0)
+	for _, value := range domains.Candidates(coordinate) {
+		// matchedTo holds the index of the coordinate every value is matched to
+		matchedTo := map[int]int{value: -1}
+		complete := true
+		for i := range others {
+			if !augment(candidates, matchedTo, i, make(map[int]bool)) {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			supported = append(supported, value)
+		}
+	}
+	return supported
+}
+
+// augment tries to match the coordinate at index i to a value, moving other
+// coordinates to different values if needed. Values matched to -1 are taken.
+func augment(candidates [][]int, matchedTo map[int]int, i int, visited map[int]bool) bool {
+	for _, value := range candidates[i] {
+		if visited[value] {
+			continue
+		}
+		visited[value] = true
+		matched, ok := matchedTo[value]
+		if ok && matched < 0 {
+			continue
+		}
+		if !ok || augment(candidates, matchedTo, matched, visited) {
+			matchedTo[value] = i
+			return true
+		}
+	}
+	return false
+}
+
 func RowConstraint(row int, coordinates []sudoku.Coordinate) (*NoRepeatConstraint, error) {
 	rowCoords := make([]sudoku.Coordinate, 0)
 	for _, coordinate := range coordinates {
@@ -160,3 +246,23 @@ func BoxConstraints(coordinates []sudoku.Coordinate) ([]NoRepeatConstraint, erro
 	}
 	return constraints, nil
 }
+
+// AntiKnightConstraints creates a constraint for every pair of coordinates
+// that are a knight's move in chess apart, so these can't have the same value.
+func AntiKnightConstraints(coordinates []sudoku.Coordinate) []NoRepeatConstraint {
+	inSudoku := make(map[sudoku.Coordinate]bool, len(coordinates))
+	for _, coordinate := range coordinates {
+		inSudoku[coordinate] = true
+	}
+	constraints := make([]NoRepeatConstraint, 0)
+	for _, coordinate := range coordinates {
+		// only the moves to later rows, so that every pair is added once
+		for _, move := range [][2]int{{1, -2}, {1, 2}, {2, -1}, {2, 1}} {
+			other := sudoku.Coordinate{Row: coordinate.Row + move[0], Col: coordinate.Col + move[1]}
+			if inSudoku[other] {
+				constraints = append(constraints, NoRepeatConstraint{Coordinates: []sudoku.Coordinate{coordinate, other}})
+			}
+		}
+	}
+	return constraints
+}
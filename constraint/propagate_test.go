@@ -0,0 +1,269 @@
+package constraint_test
+
+import (
+	"fmt"
+	"slices"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDomains holds the candidates of every coordinate. Coordinates with a
+// single candidate are filled in.
+type testDomains map[sudoku.Coordinate][]int
+
+var _ sudoku.Domains = testDomains{}
+
+func (d testDomains) Get(coordinate sudoku.Coordinate) (int, bool) {
+	if len(d[coordinate]) != 1 {
+		return 0, false
+	}
+	return d[coordinate][0], true
+}
+
+func (d testDomains) Candidates(coordinate sudoku.Coordinate) []int {
+	return d[coordinate]
+}
+
+func (d testDomains) CanBe(coordinate sudoku.Coordinate, value int) bool {
+	return slices.Contains(d[coordinate], value)
+}
+
+func (d testDomains) Bounds(coordinate sudoku.Coordinate) (int, int) {
+	if len(d[coordinate]) == 0 {
+		return 0, 0
+	}
+	return slices.Min(d[coordinate]), slices.Max(d[coordinate])
+}
+
+func (d testDomains) Restrict(coordinate sudoku.Coordinate, keep func(int) bool) error {
+	kept := make([]int, 0, len(d[coordinate]))
+	for _, value := range d[coordinate] {
+		if keep(value) {
+			kept = append(kept, value)
+		}
+	}
+	if len(kept) == 0 {
+		return fmt.Errorf("no values left at %v", coordinate)
+	}
+	d[coordinate] = kept
+	return nil
+}
+
+func TestPropagate(t *testing.T) {
+	r1c1 := sudoku.Coordinate{Row: 1, Col: 1}
+	r1c2 := sudoku.Coordinate{Row: 1, Col: 2}
+	r1c3 := sudoku.Coordinate{Row: 1, Col: 3}
+	r2c1 := sudoku.Coordinate{Row: 2, Col: 1}
+	r2c2 := sudoku.Coordinate{Row: 2, Col: 2}
+	arrow, err := constraint.NewArrowConstraint(r1c1, []sudoku.Coordinate{r1c2, r1c3})
+	require.NoError(t, err)
+	cardinality, err := constraint.NewCardinalityConstraint([]sudoku.Coordinate{r1c1, r1c2, r1c3}, map[int]int{2: 2})
+	require.NoError(t, err)
+	expression, err := constraint.NewExpressionConstraint("R1C1 + R1C2 == 5")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		propagator sudoku.Propagator
+		domains    testDomains
+		expected   testDomains
+	}{
+		{
+			name:       "NoRepeat",
+			propagator: constraint.NoRepeatConstraint{Coordinates: []sudoku.Coordinate{r1c1, r1c2, r1c3}},
+			domains:    testDomains{r1c1: {2}, r1c2: {1, 2, 3}, r1c3: {2, 3}},
+			expected:   testDomains{r1c1: {2}, r1c2: {1, 3}, r1c3: {3}},
+		},
+		{
+			name:       "FixedValue",
+			propagator: constraint.FixedValueConstraint{Coordinate: r1c1, Value: 3},
+			domains:    testDomains{r1c1: {1, 2, 3}},
+			expected:   testDomains{r1c1: {3}},
+		},
+		{
+			name:       "LessThan",
+			propagator: constraint.LessThanConstraint{Smaller: r1c1, Bigger: r1c2},
+			domains:    testDomains{r1c1: {1, 2, 3, 4}, r1c2: {1, 2, 3}},
+			expected:   testDomains{r1c1: {1, 2}, r1c2: {2, 3}},
+		},
+		{
+			name:       "SameSum",
+			propagator: arrow,
+			domains:    testDomains{r1c1: {1, 2, 3, 4}, r1c2: {1, 2}, r1c3: {1, 2}},
+			expected:   testDomains{r1c1: {2, 3, 4}, r1c2: {1, 2}, r1c3: {1, 2}},
+		},
+		{
+			// both groups can only have a sum between 9 and 11
+			name:       "SameSumGroups",
+			propagator: constraint.SameSumConstraint{Coordinates1: []sudoku.Coordinate{r1c1, r1c2}, Coordinates2: []sudoku.Coordinate{r2c1, r2c2}},
+			domains:    testDomains{r1c1: {1, 2, 3, 4, 5, 6, 7, 8, 9}, r1c2: {1, 2}, r2c1: {1, 2, 3, 4, 5, 6, 7, 8, 9}, r2c2: {8}},
+			expected:   testDomains{r1c1: {7, 8, 9}, r1c2: {1, 2}, r2c1: {1, 2, 3}, r2c2: {8}},
+		},
+		{
+			name:       "CardinalityForcesValues",
+			propagator: cardinality,
+			domains:    testDomains{r1c1: {1, 2}, r1c2: {2, 3}, r1c3: {1, 3}},
+			expected:   testDomains{r1c1: {2}, r1c2: {2}, r1c3: {1, 3}},
+		},
+		{
+			name:       "CardinalityRemovesValues",
+			propagator: cardinality,
+			domains:    testDomains{r1c1: {2}, r1c2: {2}, r1c3: {1, 2}},
+			expected:   testDomains{r1c1: {2}, r1c2: {2}, r1c3: {1}},
+		},
+		{
+			name:       "Sum",
+			propagator: constraint.SumConstraint{Coordinates: []sudoku.Coordinate{r1c1, r1c2, r1c3}, Sum: 6},
+			domains:    testDomains{r1c1: {1, 2, 3, 4, 5}, r1c2: {1, 2}, r1c3: {1, 2}},
+			expected:   testDomains{r1c1: {2, 3, 4}, r1c2: {1, 2}, r1c3: {1, 2}},
+		},
+		{
+			name:       "ExpressionWithOneEmptyCell",
+			propagator: expression,
+			domains:    testDomains{r1c1: {2}, r1c2: {1, 2, 3, 4}},
+			expected:   testDomains{r1c1: {2}, r1c2: {3}},
+		},
+		{
+			name:       "ExpressionWithTwoEmptyCells",
+			propagator: expression,
+			domains:    testDomains{r1c1: {1, 2}, r1c2: {1, 2, 3, 4}},
+			expected:   testDomains{r1c1: {1, 2}, r1c2: {1, 2, 3, 4}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, test.propagator.Propagate(test.domains))
+			assert.Equal(t, test.expected, test.domains)
+		})
+	}
+}
+
+func TestPropagateFailsWhenUnsatisfiable(t *testing.T) {
+	r1c1 := sudoku.Coordinate{Row: 1, Col: 1}
+	r1c2 := sudoku.Coordinate{Row: 1, Col: 2}
+	r2c1 := sudoku.Coordinate{Row: 2, Col: 1}
+	r2c2 := sudoku.Coordinate{Row: 2, Col: 2}
+
+	tests := []struct {
+		name       string
+		propagator sudoku.Propagator
+		domains    testDomains
+	}{
+		{
+			name:       "LessThan",
+			propagator: constraint.LessThanConstraint{Smaller: r1c1, Bigger: r1c2},
+			domains:    testDomains{r1c1: {3, 4}, r1c2: {1, 2}},
+		},
+		{
+			// the first group adds up to at most 4, the second to at least 5
+			name:       "SameSum",
+			propagator: constraint.SameSumConstraint{Coordinates1: []sudoku.Coordinate{r1c1, r1c2}, Coordinates2: []sudoku.Coordinate{r2c1, r2c2}},
+			domains:    testDomains{r1c1: {1, 2}, r1c2: {1, 2}, r2c1: {3, 4, 5}, r2c2: {2, 3}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, test.propagator.Propagate(test.domains))
+		})
+	}
+}
+
+func TestSupported(t *testing.T) {
+	r1c1 := sudoku.Coordinate{Row: 1, Col: 1}
+	r1c2 := sudoku.Coordinate{Row: 1, Col: 2}
+	r1c3 := sudoku.Coordinate{Row: 1, Col: 3}
+	r1c4 := sudoku.Coordinate{Row: 1, Col: 4}
+	r1c5 := sudoku.Coordinate{Row: 1, Col: 5}
+	arrow, err := constraint.NewArrowConstraint(r1c1, []sudoku.Coordinate{r1c2, r1c3})
+	require.NoError(t, err)
+	product, err := constraint.NewExpressionConstraint("R1C1 * R1C2 == 8 && R1C1 < R1C2")
+	require.NoError(t, err)
+	fiveCells, err := constraint.NewExpressionConstraint("R1C1 + R1C2 + R1C3 + R1C4 + R1C5 == 5")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		supporter  sudoku.Supporter
+		domains    testDomains
+		coordinate sudoku.Coordinate
+		expected   []int
+	}{
+		{
+			// r1c2 and r1c3 need 1 and 2 between them, so r1c1 can only be 3
+			name:       "NoRepeat",
+			supporter:  constraint.NoRepeatConstraint{Coordinates: []sudoku.Coordinate{r1c1, r1c2, r1c3}},
+			domains:    testDomains{r1c1: {1, 2, 3}, r1c2: {1, 2}, r1c3: {1, 2}},
+			coordinate: r1c1,
+			expected:   []int{3},
+		},
+		{
+			name:       "LessThan",
+			supporter:  constraint.LessThanConstraint{Smaller: r1c1, Bigger: r1c2},
+			domains:    testDomains{r1c1: {1, 2, 3, 4}, r1c2: {1, 2, 3}},
+			coordinate: r1c1,
+			expected:   []int{1, 2},
+		},
+		{
+			name:       "SameSumCircle",
+			supporter:  arrow,
+			domains:    testDomains{r1c1: {1, 2, 5, 7}, r1c2: {1, 2}, r1c3: {3}},
+			coordinate: r1c1,
+			expected:   []int{5},
+		},
+		{
+			name:       "SameSumPath",
+			supporter:  arrow,
+			domains:    testDomains{r1c1: {4, 5}, r1c2: {1, 2, 3}, r1c3: {3}},
+			coordinate: r1c2,
+			expected:   []int{1, 2},
+		},
+		{
+			// r1c2 and r1c3 can only add up to 2, 4 or 6
+			name:       "Sum",
+			supporter:  constraint.SumConstraint{Coordinates: []sudoku.Coordinate{r1c1, r1c2, r1c3}, Sum: 8},
+			domains:    testDomains{r1c1: {2, 3, 4, 5}, r1c2: {1, 3}, r1c3: {1, 3}},
+			coordinate: r1c1,
+			expected:   []int{2, 4},
+		},
+		{
+			// r1c3 can't be 1, so r1c1 has to be the second 1
+			name:       "CardinalityMissing",
+			supporter:  constraint.CardinalityConstraint{Coordinates: []sudoku.Coordinate{r1c1, r1c2, r1c3}, Counts: map[int]int{1: 2}},
+			domains:    testDomains{r1c1: {1, 2, 3}, r1c2: {1}, r1c3: {2, 3}},
+			coordinate: r1c1,
+			expected:   []int{1},
+		},
+		{
+			name:       "CardinalityPlaced",
+			supporter:  constraint.CardinalityConstraint{Coordinates: []sudoku.Coordinate{r1c1, r1c2, r1c3}, Counts: map[int]int{1: 1}},
+			domains:    testDomains{r1c1: {1, 2, 3}, r1c2: {1}, r1c3: {1, 2, 3}},
+			coordinate: r1c1,
+			expected:   []int{2, 3},
+		},
+		{
+			name:       "Expression",
+			supporter:  product,
+			domains:    testDomains{r1c1: {1, 2, 3, 4}, r1c2: {1, 2, 3, 4, 5, 6, 7, 8}},
+			coordinate: r1c1,
+			expected:   []int{1, 2},
+		},
+		{
+			// only 1 has support, but four other empty cells are too many
+			// to search
+			name:       "ExpressionTooManyCells",
+			supporter:  fiveCells,
+			domains:    testDomains{r1c1: {1, 2}, r1c2: {1, 2}, r1c3: {1, 2}, r1c4: {1, 2}, r1c5: {1, 2}},
+			coordinate: r1c1,
+			expected:   []int{1, 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.supporter.Supported(test.domains, test.coordinate))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package constraint
 
 import (
 	"fmt"
+	"slices"
 	"sudoku-solver/sudoku"
 )
 
@@ -36,7 +37,132 @@ func (c SameSumConstraint) IsViolated(solution sudoku.Solution) bool {
 }
 
 func (c SameSumConstraint) ConstrainedCoordinates() []sudoku.Coordinate {
-	return append(c.Coordinates1, c.Coordinates2...)
+	return append(slices.Clip(c.Coordinates1), c.Coordinates2...)
+}
+
+var _ sudoku.Propagator = SameSumConstraint{}
+
+// Propagate narrows the values of both groups to the range of sums they can
+// still have in common. Every cell only keeps the values that can reach that
+// range together with the smallest and biggest values of the other cells in
+// its group. This is repeated until nothing changes anymore.
+func (c SameSumConstraint) Propagate(domains sudoku.Domains) error {
+	for {
+		min1, max1 := sumBounds(domains, c.Coordinates1)
+		min2, max2 := sumBounds(domains, c.Coordinates2)
+		lower, upper := max(min1, min2), min(max1, max2)
+		if lower > upper {
+			return fmt.Errorf("sums of %v and %v can no longer be equal", c.Coordinates1, c.Coordinates2)
+		}
+		changed1, err := constrainSumGroup(domains, c.Coordinates1, min1, max1, lower, upper)
+		if err != nil {
+			return err
+		}
+		changed2, err := constrainSumGroup(domains, c.Coordinates2, min2, max2, lower, upper)
+		if err != nil {
+			return err
+		}
+		if !changed1 && !changed2 {
+			return nil
+		}
+	}
+}
+
+// sumBounds returns the smallest and biggest sum the given coordinates can
+// still have.
+func sumBounds(domains sudoku.Domains, coordinates []sudoku.Coordinate) (int, int) {
+	sumMin, sumMax := 0, 0
+	for _, coord := range coordinates {
+		cellMin, cellMax := domains.Bounds(coord)
+		sumMin += cellMin
+		sumMax += cellMax
+	}
+	return sumMin, sumMax
+}
+
+// constrainSumGroup removes all values from the cells of the group that can't
+// be part of a sum between lower and upper. sumMin and sumMax are the current
+// bounds of the sum of the group. It returns whether any cell was changed.
+func constrainSumGroup(domains sudoku.Domains, coordinates []sudoku.Coordinate, sumMin, sumMax, lower, upper int) (bool, error) {
+	changed := false
+	for _, coord := range coordinates {
+		if _, ok := domains.Get(coord); ok {
+			continue
+		}
+		cellMin, cellMax := domains.Bounds(coord)
+		othersMin, othersMax := sumMin-cellMin, sumMax-cellMax
+		keep := func(value int) bool {
+			return value+othersMin <= upper && value+othersMax >= lower
+		}
+		removed := false
+		for _, value := range domains.Candidates(coord) {
+			if !keep(value) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			continue
+		}
+		if err := domains.Restrict(coord, keep); err != nil {
+			return false, fmt.Errorf("coordinate %v can't reach a sum between %d and %d: %w", coord, lower, upper, err)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+var _ sudoku.Supporter = SameSumConstraint{}
+
+// Supported returns the values of the coordinate for which the other cells of
+// its group can reach a sum that the other group can reach as well. The
+// reachable sums are calculated for every group independently, so
+// coordinates that are part of both groups can get different values.
+func (c SameSumConstraint) Supported(domains sudoku.Domains, coordinate sudoku.Coordinate) []int {
+	supported := make([]int, 0)
+	for _, value := range domains.Candidates(coordinate) {
+		in1 := reachableSums(domains, c.Coordinates1, coordinate, value)
+		in2 := reachableSums(domains, c.Coordinates2, coordinate, value)
+		for sum := range in1 {
+			if in2[sum] {
+				supported = append(supported, value)
+				break
+			}
+		}
+	}
+	return supported
+}
+
+// reachableSums returns all sums the coordinates can have if the given
+// coordinate has the given value.
+func reachableSums(domains sudoku.Domains, coordinates []sudoku.Coordinate, fixed sudoku.Coordinate, value int) map[int]bool {
+	sums := map[int]bool{0: true}
+	for _, coord := range coordinates {
+		values := domains.Candidates(coord)
+		if coord == fixed {
+			values = []int{value}
+		}
+		next := make(map[int]bool, len(sums)*len(values))
+		for sum := range sums {
+			for _, v := range values {
+				next[sum+v] = true
+			}
+		}
+		sums = next
+	}
+	return sums
+}
+
+// NewSameSumConstraint creates a new SameSumConstraint that requires that the
+// values in coordinates1 sum up to the same value as the values in coordinates2.
+func NewSameSumConstraint(coordinates1, coordinates2 []sudoku.Coordinate) (*SameSumConstraint, error) {
+	if len(coordinates1) == 0 || len(coordinates2) == 0 {
+		return nil, fmt.Errorf("same sum groups must not be empty")
+	}
+	return &SameSumConstraint{
+		Coordinates1: coordinates1,
+		Coordinates2: coordinates2,
+	}, nil
 }
 
 // NewArrowConstraint creates a new SameSumConstraint that requires that the sum
@@ -0,0 +1,94 @@
+package constraint
+
+import (
+	"fmt"
+	"slices"
+	"sudoku-solver/sudoku"
+)
+
+// SumConstraint is a constraint that requires the values at the coordinates
+// in Coordinates to add up to Sum.
+type SumConstraint struct {
+	Coordinates []sudoku.Coordinate
+	Sum         int
+}
+
+var _ sudoku.Constraint = SumConstraint{}
+
+func (c SumConstraint) IsViolated(solution sudoku.Solution) bool {
+	sum := 0
+	for _, coord := range c.Coordinates {
+		value, ok := solution.Get(coord)
+		if !ok {
+			return false
+		}
+		sum += value
+	}
+	return sum != c.Sum
+}
+
+func (c SumConstraint) ConstrainedCoordinates() []sudoku.Coordinate {
+	return c.Coordinates
+}
+
+var _ sudoku.Propagator = SumConstraint{}
+
+// Propagate removes all values that can't reach the sum together with the
+// smallest and biggest values of the other cells. This is repeated until
+// nothing changes anymore.
+func (c SumConstraint) Propagate(domains sudoku.Domains) error {
+	for {
+		sumMin, sumMax := sumBounds(domains, c.Coordinates)
+		if c.Sum < sumMin || c.Sum > sumMax {
+			return fmt.Errorf("values of %v can no longer add up to %d", c.Coordinates, c.Sum)
+		}
+		changed, err := constrainSumGroup(domains, c.Coordinates, sumMin, sumMax, c.Sum, c.Sum)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+	}
+}
+
+var _ sudoku.Supporter = SumConstraint{}
+
+// Supported returns the values of the coordinate for which the other cells can
+// reach the sum.
+func (c SumConstraint) Supported(domains sudoku.Domains, coordinate sudoku.Coordinate) []int {
+	supported := make([]int, 0)
+	for _, value := range domains.Candidates(coordinate) {
+		if reachableSums(domains, c.Coordinates, coordinate, value)[c.Sum] {
+			supported = append(supported, value)
+		}
+	}
+	return supported
+}
+
+// NewSumConstraint creates a new SumConstraint that requires the values at the
+// coordinates to add up to sum.
+func NewSumConstraint(coordinates []sudoku.Coordinate, sum int) (*SumConstraint, error) {
+	if len(coordinates) == 0 {
+		return nil, fmt.Errorf("sum coordinates must not be empty")
+	}
+	return &SumConstraint{Coordinates: coordinates, Sum: sum}, nil
+}
+
+// NewKillerCageConstraints creates the constraints of a killer cage: the
+// values in the cage don't repeat and add up to sum.
+func NewKillerCageConstraints(coordinates []sudoku.Coordinate, sum int) (*NoRepeatConstraint, *SumConstraint, error) {
+	for i, coord := range coordinates {
+		if slices.Contains(coordinates[:i], coord) {
+			return nil, nil, fmt.Errorf("killer cage contains %v twice", coord)
+		}
+	}
+	if !sudoku.IsConnected(coordinates) {
+		return nil, nil, fmt.Errorf("killer cage %v is not connected", coordinates)
+	}
+	sumConstraint, err := NewSumConstraint(coordinates, sum)
+	if err != nil {
+		return nil, nil, fmt.Errorf("killer cage: %w", err)
+	}
+	return &NoRepeatConstraint{Coordinates: coordinates}, sumConstraint, nil
+}
@@ -0,0 +1,62 @@
+package constraint_test
+
+import (
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKillerCageConstraints(t *testing.T) {
+	cage := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 2, Col: 2}}
+	noRepeat, sum, err := constraint.NewKillerCageConstraints(cage, 12)
+	require.NoError(t, err)
+	assert.Equal(t, cage, noRepeat.Coordinates)
+	assert.Equal(t, constraint.SumConstraint{Coordinates: cage, Sum: 12}, *sum)
+
+	_, _, err = constraint.NewKillerCageConstraints([]sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 2, Col: 2}}, 3)
+	assert.Error(t, err, "cage is not connected")
+	_, _, err = constraint.NewKillerCageConstraints([]sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 1}}, 3)
+	assert.Error(t, err, "cage contains a cell twice")
+	_, _, err = constraint.NewKillerCageConstraints(nil, 3)
+	assert.Error(t, err, "cage is empty")
+}
+
+func TestNewThermometerConstraints(t *testing.T) {
+	r1c1 := sudoku.Coordinate{Row: 1, Col: 1}
+	r2c2 := sudoku.Coordinate{Row: 2, Col: 2}
+	r2c3 := sudoku.Coordinate{Row: 2, Col: 3}
+	constraints, err := constraint.NewThermometerConstraints([]sudoku.Coordinate{r1c1, r2c2, r2c3})
+	require.NoError(t, err)
+	assert.Equal(t, []constraint.LessThanConstraint{
+		{Smaller: r1c1, Bigger: r2c2},
+		{Smaller: r2c2, Bigger: r2c3},
+	}, constraints)
+
+	_, err = constraint.NewThermometerConstraints([]sudoku.Coordinate{r1c1})
+	assert.Error(t, err, "thermometer is too short")
+	_, err = constraint.NewThermometerConstraints([]sudoku.Coordinate{r1c1, r2c3})
+	assert.Error(t, err, "cells don't touch")
+	_, err = constraint.NewThermometerConstraints([]sudoku.Coordinate{r1c1, r2c2, r1c1})
+	assert.Error(t, err, "thermometer contains a cell twice")
+}
+
+func TestAntiKnightConstraints(t *testing.T) {
+	coordinates := make([]sudoku.Coordinate, 0, 81)
+	for row := 1; row <= 9; row++ {
+		for col := 1; col <= 9; col++ {
+			coordinates = append(coordinates, sudoku.Coordinate{Row: row, Col: col})
+		}
+	}
+	constraints := constraint.AntiKnightConstraints(coordinates)
+	// the pairs one row and two columns or two rows and one column apart
+	assert.Len(t, constraints, 2*8*7+2*7*8)
+	for _, c := range constraints {
+		require.Len(t, c.Coordinates, 2)
+		dRow := c.Coordinates[1].Row - c.Coordinates[0].Row
+		dCol := c.Coordinates[1].Col - c.Coordinates[0].Col
+		assert.Equal(t, 5, dRow*dRow+dCol*dCol)
+	}
+}
@@ -0,0 +1,304 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/constraint"
+	"sudoku-solver/rating"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+)
+
+// DefaultAttempts is the number of solutions that are tried if the options
+// don't set the attempts.
+const DefaultAttempts = 20
+
+// searchMode is used for all uniqueness checks because it is the fastest mode
+// for sudokus with few clues.
+var searchMode = backtrack.ModePencilMarkMRV
+
+// Options controls how a puzzle is generated.
+type Options struct {
+	// Seed seeds the random numbers. The same seed and options generate the
+	// same puzzle.
+	Seed int64
+	// Clues is the number of clues the puzzle keeps. Zero removes clues as
+	// long as the solution stays unique.
+	Clues int
+	// Level is the level of the puzzle. Clues are only removed until the
+	// puzzle reaches the level, unless Clues is set as well. Empty accepts
+	// every level.
+	Level rating.Level
+	// Attempts is the number of random solutions that are tried until one of
+	// them leads to a puzzle with the clues and the level. Zero tries
+	// DefaultAttempts solutions.
+	Attempts int
+}
+
+// Puzzle is a generated sudoku with a unique solution.
+type Puzzle struct {
+	// Sudoku holds the rules and a FixedValueConstraint for every clue.
+	Sudoku sudoku.Sudoku
+	// Clues holds the values that are given.
+	Clues map[sudoku.Coordinate]int
+	// Solution holds the value of every coordinate.
+	Solution map[sudoku.Coordinate]int
+	// Rating is the rating of the sudoku.
+	Rating rating.Rating
+	// Constraints describes the rules of the sudoku without the clues. It is
+	// nil for puzzles of Generate.
+	Constraints []sudokuio.JSONConstraint
+}
+
+// JSON returns the sudoku in the JSON format that sudokuio.ParseJSON reads.
+// It returns an error if the puzzle has no Constraints, because the rules of
+// Generate can't be described.
+func (p Puzzle) JSON() (sudokuio.JSONSudoku, error) {
+	if p.Constraints == nil {
+		return sudokuio.JSONSudoku{}, fmt.Errorf("puzzle has no description of its rules")
+	}
+	return sudokuio.JSONSudoku{
+		Field:       sudokuio.JSONField{Type: "normal", Rows: sudokuio.FormatRows(p.Sudoku)},
+		Constraints: p.Constraints,
+	}, nil
+}
+
+// Classic generates a 9x9 sudoku with the normal sudoku rules.
+func Classic(ctx context.Context, options Options) (Puzzle, error) {
+	return Variant(ctx, Rules{}, options)
+}
+
+// Generate fills the rules with a random solution and removes its values
+// in random order as long as the solution stays unique. It stops when the
+// puzzle has as many clues or the level the options ask for. If the puzzle
+// misses the clues or the level, it starts over with another solution. It
+// returns an error if the rules have no solution, no attempt succeeds or the
+// context is cancelled. The constraints of the puzzle are not described,
+// because the rules can be any sudoku.
+func Generate(ctx context.Context, rules sudoku.Sudoku, options Options) (Puzzle, error) {
+	return generate(ctx, rules, options, nil)
+}
+
+// placement adds constraints to the rules that the solution satisfies. It
+// returns the extended rules, the description of all their constraints and
+// false if the constraints can't be placed for the solution.
+type placement func(rng *rand.Rand, solution map[sudoku.Coordinate]int) (sudoku.Sudoku, []sudokuio.JSONConstraint, bool, error)
+
+// generate works like Generate but lets place add constraints for every
+// solution before the clues are removed.
+func generate(ctx context.Context, rules sudoku.Sudoku, options Options, place placement) (Puzzle, error) {
+	if options.Clues < 0 || options.Clues > len(rules.Coordinates) {
+		return Puzzle{}, fmt.Errorf("clues must be between 0 and %d, got %d", len(rules.Coordinates), options.Clues)
+	}
+	if options.Level != "" && !slices.Contains(rating.Levels(), options.Level) {
+		return Puzzle{}, fmt.Errorf("unknown level %q", options.Level)
+	}
+	attempts := options.Attempts
+	if attempts == 0 {
+		attempts = DefaultAttempts
+	}
+	rng := rand.New(rand.NewSource(options.Seed))
+	for attempt := 0; attempt < attempts; attempt++ {
+		solution, err := randomSolution(ctx, rng, rules)
+		if err != nil {
+			return Puzzle{}, err
+		}
+		puzzleRules := rules
+		var constraints []sudokuio.JSONConstraint
+		if place != nil {
+			var ok bool
+			puzzleRules, constraints, ok, err = place(rng, solution)
+			if err != nil {
+				return Puzzle{}, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		puzzle, ok, err := removeClues(ctx, rng, puzzleRules, solution, options)
+		if err != nil {
+			return Puzzle{}, err
+		}
+		if ok {
+			puzzle.Constraints = constraints
+			return puzzle, nil
+		}
+	}
+	return Puzzle{}, fmt.Errorf("no puzzle with the requested clues and level found in %d attempts", attempts)
+}
+
+// Variant generates a 9x9 sudoku with the normal sudoku rules and the
+// variant rules. It works like Generate, but places the killer cages and
+// thermometers for every random solution so that the solution satisfies them.
+func Variant(ctx context.Context, rules Rules, options Options) (Puzzle, error) {
+	if rules.Thermometers < 0 {
+		return Puzzle{}, fmt.Errorf("thermometers must not be negative, got %d", rules.Thermometers)
+	}
+	base := []sudokuio.JSONConstraint{sudokuio.NormalSudokuRulesJSON()}
+	if rules.AntiKnight {
+		base = append(base, sudokuio.AntiKnightJSON())
+	}
+	baseSudoku, err := emptyJSON(base).Sudoku()
+	if err != nil {
+		return Puzzle{}, fmt.Errorf("create rules: %w", err)
+	}
+	return generate(ctx, *baseSudoku, options, func(rng *rand.Rand, solution map[sudoku.Coordinate]int) (sudoku.Sudoku, []sudokuio.JSONConstraint, bool, error) {
+		variants, ok := placeVariants(rng, rules, baseSudoku.Coordinates, solution)
+		if !ok {
+			return sudoku.Sudoku{}, nil, false, nil
+		}
+		constraints := append(slices.Clip(base), variants...)
+		sudok, err := emptyJSON(constraints).Sudoku()
+		if err != nil {
+			return sudoku.Sudoku{}, nil, false, fmt.Errorf("create rules: %w", err)
+		}
+		return *sudok, constraints, true, nil
+	})
+}
+
+// emptyJSON returns an empty 9x9 sudoku with the constraints.
+func emptyJSON(constraints []sudokuio.JSONConstraint) sudokuio.JSONSudoku {
+	rows := make([]string, 9)
+	for i := range rows {
+		rows[i] = strings.Repeat("-", 9)
+	}
+	return sudokuio.JSONSudoku{
+		Field:       sudokuio.JSONField{Type: "normal", Rows: rows},
+		Constraints: constraints,
+	}
+}
+
+// randomSolution fills the cells in random order with random values that
+// keep the rules solvable until the values only allow one solution.
+func randomSolution(ctx context.Context, rng *rand.Rand, rules sudoku.Sudoku) (map[sudoku.Coordinate]int, error) {
+	uniqueness, err := backtrack.CheckUniqueness(ctx, searchMode, rules)
+	if err != nil {
+		return nil, fmt.Errorf("check uniqueness: %w", err)
+	}
+	if !uniqueness.HasSolution() {
+		return nil, fmt.Errorf("rules have no solution")
+	}
+	current := solutionValues(rules, uniqueness.Solutions[0])
+	if uniqueness.IsUnique() {
+		return current, nil
+	}
+
+	values := make(map[sudoku.Coordinate]int)
+	for _, i := range rng.Perm(len(rules.Coordinates)) {
+		coord := rules.Coordinates[i]
+		for _, j := range rng.Perm(len(rules.PossibleValues)) {
+			value := rules.PossibleValues[j]
+			if current[coord] == value {
+				// the current solution already has the value
+				values[coord] = value
+				break
+			}
+			values[coord] = value
+			uniqueness, err := backtrack.CheckUniqueness(ctx, searchMode, withClues(rules, values))
+			if err != nil {
+				return nil, fmt.Errorf("check uniqueness: %w", err)
+			}
+			if !uniqueness.HasSolution() {
+				delete(values, coord)
+				continue
+			}
+			current = solutionValues(rules, uniqueness.Solutions[0])
+			if uniqueness.IsUnique() {
+				return current, nil
+			}
+			break
+		}
+	}
+	return current, nil
+}
+
+// removeClues removes the values of the solution in random order as long as
+// the solution stays unique and the puzzle doesn't get harder than the level
+// of the options. It returns false if the puzzle misses the clues or the level
+// of the options.
+func removeClues(ctx context.Context, rng *rand.Rand, rules sudoku.Sudoku, solution map[sudoku.Coordinate]int, options Options) (Puzzle, bool, error) {
+	clues := make(map[sudoku.Coordinate]int, len(solution))
+	for coord, value := range solution {
+		clues[coord] = value
+	}
+	for _, i := range rng.Perm(len(rules.Coordinates)) {
+		if options.Clues > 0 && len(clues) <= options.Clues {
+			break
+		}
+		coord := rules.Coordinates[i]
+		value := clues[coord]
+		delete(clues, coord)
+		puzzle := withClues(rules, clues)
+		uniqueness, err := backtrack.CheckUniqueness(ctx, searchMode, puzzle)
+		if err != nil {
+			return Puzzle{}, false, fmt.Errorf("check uniqueness: %w", err)
+		}
+		if !uniqueness.IsUnique() {
+			clues[coord] = value
+			continue
+		}
+		if options.Level == "" {
+			continue
+		}
+		r, err := rating.Rate(ctx, puzzle)
+		if err != nil {
+			return Puzzle{}, false, fmt.Errorf("rate: %w", err)
+		}
+		if levelRank(r.Level()) > levelRank(options.Level) {
+			clues[coord] = value
+			continue
+		}
+		if options.Clues == 0 && r.Level() == options.Level {
+			break
+		}
+	}
+
+	puzzle := Puzzle{
+		Sudoku:   withClues(rules, clues),
+		Clues:    clues,
+		Solution: solution,
+	}
+	if options.Clues > 0 && len(clues) != options.Clues {
+		return Puzzle{}, false, nil
+	}
+	r, err := rating.Rate(ctx, puzzle.Sudoku)
+	if err != nil {
+		return Puzzle{}, false, fmt.Errorf("rate: %w", err)
+	}
+	puzzle.Rating = r
+	if options.Level != "" && r.Level() != options.Level {
+		return Puzzle{}, false, nil
+	}
+	return puzzle, true, nil
+}
+
+// withClues returns the rules with a FixedValueConstraint for every clue in
+// the order of the coordinates.
+func withClues(rules sudoku.Sudoku, clues map[sudoku.Coordinate]int) sudoku.Sudoku {
+	puzzle := rules
+	puzzle.Constraints = slices.Clip(rules.Constraints)
+	for _, coord := range rules.Coordinates {
+		if value, ok := clues[coord]; ok {
+			puzzle.Constraints = append(puzzle.Constraints, constraint.FixedValueConstraint{Coordinate: coord, Value: value})
+		}
+	}
+	return puzzle
+}
+
+func solutionValues(sudok sudoku.Sudoku, solution sudoku.Solution) map[sudoku.Coordinate]int {
+	values := make(map[sudoku.Coordinate]int, len(sudok.Coordinates))
+	for _, coord := range sudok.Coordinates {
+		if value, ok := solution.Get(coord); ok {
+			values[coord] = value
+		}
+	}
+	return values
+}
+
+func levelRank(level rating.Level) int {
+	return slices.Index(rating.Levels(), level)
+}
@@ -0,0 +1,104 @@
+package generate_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/generate"
+	"sudoku-solver/rating"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassicIsReproducible(t *testing.T) {
+	ctx := context.Background()
+	puzzle1, err := generate.Classic(ctx, generate.Options{Seed: 1})
+	require.NoError(t, err)
+	puzzle2, err := generate.Classic(ctx, generate.Options{Seed: 1})
+	require.NoError(t, err)
+	assert.Equal(t, puzzle1.Clues, puzzle2.Clues)
+	assert.Equal(t, puzzle1.Solution, puzzle2.Solution)
+
+	puzzle3, err := generate.Classic(ctx, generate.Options{Seed: 2})
+	require.NoError(t, err)
+	assert.NotEqual(t, puzzle1.Solution, puzzle3.Solution)
+}
+
+func TestClassicHasUniqueSolution(t *testing.T) {
+	puzzle, err := generate.Classic(context.Background(), generate.Options{Seed: 3})
+	require.NoError(t, err)
+	require.Len(t, puzzle.Solution, 81)
+	assert.True(t, puzzle.Sudoku.IsSolved(sudoku.MapSolution(puzzle.Solution)))
+
+	uniqueness, err := backtrack.CheckUniqueness(context.Background(), backtrack.ModeDLX, puzzle.Sudoku)
+	require.NoError(t, err)
+	assert.True(t, uniqueness.IsUnique())
+	// no clue can be removed without losing the uniqueness
+	assert.Less(t, len(puzzle.Clues), 30)
+}
+
+func TestClassicClues(t *testing.T) {
+	puzzle, err := generate.Classic(context.Background(), generate.Options{Seed: 4, Clues: 36})
+	require.NoError(t, err)
+	assert.Len(t, puzzle.Clues, 36)
+	for coord, value := range puzzle.Clues {
+		assert.Equal(t, puzzle.Solution[coord], value)
+	}
+}
+
+func TestClassicLevel(t *testing.T) {
+	for _, level := range []rating.Level{rating.LevelEasy, rating.LevelMedium} {
+		t.Run(string(level), func(t *testing.T) {
+			puzzle, err := generate.Classic(context.Background(), generate.Options{Seed: 5, Level: level})
+			require.NoError(t, err)
+			assert.Equal(t, level, puzzle.Rating.Level())
+		})
+	}
+}
+
+func TestClassicRejectsInvalidOptions(t *testing.T) {
+	_, err := generate.Classic(context.Background(), generate.Options{Clues: 82})
+	assert.Error(t, err)
+	_, err = generate.Classic(context.Background(), generate.Options{Level: "trivial"})
+	assert.Error(t, err)
+}
+
+func TestClassicOutputRoundTrips(t *testing.T) {
+	puzzle, err := generate.Classic(context.Background(), generate.Options{Seed: 6})
+	require.NoError(t, err)
+
+	fromText, err := sudokuio.ParseString(sudokuio.FormatString(puzzle.Sudoku))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, puzzle.Sudoku.Constraints, fromText.Constraints)
+
+	bytes, err := json.Marshal(sudokuio.ClassicJSON(puzzle.Sudoku))
+	require.NoError(t, err)
+	fromJSON, err := sudokuio.ParseJSON(bytes)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, puzzle.Sudoku.Constraints, fromJSON.Constraints)
+}
+
+func TestGenerateWithOtherRules(t *testing.T) {
+	rules, err := sudokuio.ParseString(strings.Repeat("-", 16))
+	require.NoError(t, err)
+	puzzle, err := generate.Generate(context.Background(), *rules, generate.Options{Seed: 7})
+	require.NoError(t, err)
+	require.Len(t, puzzle.Solution, 16)
+	assert.True(t, puzzle.Sudoku.IsSolved(sudoku.MapSolution(puzzle.Solution)))
+	assert.Nil(t, puzzle.Constraints)
+	// the 4x4 rules can't be described as JSON
+	_, err = puzzle.JSON()
+	assert.Error(t, err)
+
+	uniqueness, err := backtrack.CheckUniqueness(context.Background(), backtrack.ModePencilMarkMRV, puzzle.Sudoku)
+	require.NoError(t, err)
+	assert.True(t, uniqueness.IsUnique())
+
+	_, err = generate.Generate(context.Background(), *rules, generate.Options{Clues: 17})
+	assert.Error(t, err)
+}
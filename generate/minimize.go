@@ -0,0 +1,109 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+)
+
+// Minimal is a puzzle from which no clue can be removed without losing the
+// uniqueness of its solution.
+type Minimal struct {
+	// JSON describes the puzzle without the removed clues and constraints.
+	JSON sudokuio.JSONSudoku
+	// Sudoku is the sudoku that JSON describes.
+	Sudoku sudoku.Sudoku
+	// RemovedClues holds the clues that were removed in the order of
+	// sudokuio.JSONSudoku.Clues.
+	RemovedClues []constraint.FixedValueConstraint
+	// RemovedConstraints holds the variant constraints that were removed in
+	// the order of the description.
+	RemovedConstraints []sudokuio.JSONConstraint
+}
+
+// Minimize removes the clues of the description one at a time in the order
+// of its clues and keeps every removal after which the solution is still
+// unique. If variants is true, it continues with every whole variant
+// constraint of the description, e.g. a thermometer or killer cage with all
+// of its cells. Removing a constraint never rules out a solution, so a clue
+// that is needed once stays needed and a single pass is enough. It returns an
+// error if the description is invalid, the sudoku doesn't have a unique
+// solution or the context is cancelled.
+func Minimize(ctx context.Context, description sudokuio.JSONSudoku, variants bool) (Minimal, error) {
+	unique, err := isUnique(ctx, description)
+	if err != nil {
+		return Minimal{}, err
+	}
+	if !unique {
+		return Minimal{}, fmt.Errorf("sudoku doesn't have a unique solution")
+	}
+	clues, err := description.Clues()
+	if err != nil {
+		return Minimal{}, fmt.Errorf("read clues: %w", err)
+	}
+
+	minimal := Minimal{JSON: description}
+	removed := make(map[sudoku.Coordinate]bool, len(clues))
+	for _, clue := range clues {
+		if removed[clue.Coordinate] {
+			// the coordinate was given twice and both are gone already
+			continue
+		}
+		reduced := minimal.JSON.WithoutClue(clue.Coordinate)
+		unique, err := isUnique(ctx, reduced)
+		if err != nil {
+			return Minimal{}, err
+		}
+		if unique {
+			minimal.JSON = reduced
+			removed[clue.Coordinate] = true
+			minimal.RemovedClues = append(minimal.RemovedClues, clue)
+		}
+	}
+	if variants {
+		for i := 0; i < len(minimal.JSON.Constraints); {
+			c := minimal.JSON.Constraints[i]
+			if !c.IsVariant() {
+				i++
+				continue
+			}
+			reduced := minimal.JSON
+			reduced.Constraints = slices.Delete(slices.Clone(reduced.Constraints), i, i+1)
+			unique, err := isUnique(ctx, reduced)
+			if err != nil {
+				return Minimal{}, err
+			}
+			if !unique {
+				i++
+				continue
+			}
+			minimal.JSON = reduced
+			minimal.RemovedConstraints = append(minimal.RemovedConstraints, c)
+		}
+	}
+
+	sudok, err := minimal.JSON.Sudoku()
+	if err != nil {
+		return Minimal{}, fmt.Errorf("create sudoku: %w", err)
+	}
+	minimal.Sudoku = *sudok
+	return minimal, nil
+}
+
+// isUnique returns true if the sudoku of the description has exactly one
+// solution.
+func isUnique(ctx context.Context, description sudokuio.JSONSudoku) (bool, error) {
+	sudok, err := description.Sudoku()
+	if err != nil {
+		return false, fmt.Errorf("create sudoku: %w", err)
+	}
+	uniqueness, err := backtrack.CheckUniqueness(ctx, searchMode, *sudok)
+	if err != nil {
+		return false, fmt.Errorf("check uniqueness: %w", err)
+	}
+	return uniqueness.IsUnique(), nil
+}
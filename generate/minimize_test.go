@@ -0,0 +1,152 @@
+package generate_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/constraint"
+	"sudoku-solver/generate"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const minimizeTestSudoku = `
+24- --- -86
+--3 --- ---
+1-- --2 5--
+59- -1- --2
+--7 --- 3--
+8-- -4- -97
+--5 8-- --3
+--- --- 6--
+32- --- -19
+`
+
+// parseMinimal writes the minimal puzzle as JSON, parses it back and checks
+// that it is the sudoku of the puzzle.
+func parseMinimal(t *testing.T, minimal generate.Minimal) sudokuio.JSONSudoku {
+	bytes, err := json.Marshal(minimal.JSON)
+	require.NoError(t, err)
+	description, err := sudokuio.ParseJSONSudoku(bytes)
+	require.NoError(t, err)
+	sudok, err := sudokuio.ParseJSON(bytes)
+	require.NoError(t, err)
+	assert.Equal(t, minimal.Sudoku.Coordinates, sudok.Coordinates)
+	assert.ElementsMatch(t, minimal.Sudoku.Constraints, sudok.Constraints)
+	return description
+}
+
+// requireMinimal checks that the description has a unique solution and that
+// removing any clue or, if variants is true, any variant constraint breaks
+// the uniqueness.
+func requireMinimal(t *testing.T, description sudokuio.JSONSudoku, variants bool) {
+	require.True(t, isUnique(t, description))
+	clues, err := description.Clues()
+	require.NoError(t, err)
+	for _, clue := range clues {
+		assert.False(t, isUnique(t, description.WithoutClue(clue.Coordinate)), "clue %v is not needed", clue)
+	}
+	if !variants {
+		return
+	}
+	for i, c := range description.Constraints {
+		if !c.IsVariant() {
+			continue
+		}
+		reduced := description
+		reduced.Constraints = slices.Delete(slices.Clone(description.Constraints), i, i+1)
+		assert.False(t, isUnique(t, reduced), "%v is not needed", c)
+	}
+}
+
+func isUnique(t *testing.T, description sudokuio.JSONSudoku) bool {
+	sudok, err := description.Sudoku()
+	require.NoError(t, err)
+	uniqueness, err := backtrack.CheckUniqueness(context.Background(), backtrack.ModePencilMarkMRV, *sudok)
+	require.NoError(t, err)
+	return uniqueness.IsUnique()
+}
+
+func minimizeTestJSON(t *testing.T, constraints ...sudokuio.JSONConstraint) sudokuio.JSONSudoku {
+	sudok, err := sudokuio.ParseString(minimizeTestSudoku)
+	require.NoError(t, err)
+	description := sudokuio.ClassicJSON(*sudok)
+	description.Constraints = append(description.Constraints, constraints...)
+	return description
+}
+
+func TestMinimizeRemovesRedundantClues(t *testing.T) {
+	// over-clue the sudoku with two values of its solution, one of them as a
+	// fixedValues constraint
+	description := minimizeTestJSON(t, sudokuio.JSONConstraint{Type: "fixedValues", Values: map[string]int{"R1C3": 9}})
+	description = description.WithoutClue(sudoku.Coordinate{Row: 5, Col: 5})
+	description.Field.Rows[4] = "--7-8-3--"
+
+	clues, err := description.Clues()
+	require.NoError(t, err)
+
+	minimal, err := generate.Minimize(context.Background(), description, false)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(minimal.RemovedClues), 2)
+	assert.Empty(t, minimal.RemovedConstraints)
+	// 27 rows, columns and boxes and the clues that are left
+	assert.Len(t, minimal.Sudoku.Constraints, 27+len(clues)-len(minimal.RemovedClues))
+	requireMinimal(t, parseMinimal(t, minimal), false)
+}
+
+func TestMinimizeRemovesWholeVariantConstraints(t *testing.T) {
+	// the solution has 2 4 9 in the first row, so the thermometer and the cage
+	// hold but aren't needed with all clues
+	thermometer := sudokuio.ThermometerJSON([]sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 1, Col: 3}})
+	cage := sudokuio.KillerCageJSON([]sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}}, 6)
+	description := minimizeTestJSON(t, thermometer, cage)
+
+	minimal, err := generate.Minimize(context.Background(), description, true)
+	require.NoError(t, err)
+	reduced := parseMinimal(t, minimal)
+	requireMinimal(t, reduced, true)
+	assert.NotEmpty(t, minimal.RemovedConstraints)
+	// every constraint is either removed or kept as a whole
+	assert.ElementsMatch(t, description.Constraints, append(slices.Clone(reduced.Constraints), minimal.RemovedConstraints...))
+}
+
+func TestMinimizeKeepsNeededThermometers(t *testing.T) {
+	puzzle, err := generate.Variant(context.Background(), generate.Rules{Thermometers: 2}, generate.Options{Seed: 4})
+	require.NoError(t, err)
+
+	description, err := puzzle.JSON()
+	require.NoError(t, err)
+	minimal, err := generate.Minimize(context.Background(), description, true)
+	require.NoError(t, err)
+	reduced := parseMinimal(t, minimal)
+	requireMinimal(t, reduced, true)
+
+	var segments int
+	for _, c := range reduced.Constraints {
+		if c.Type == "thermometer" {
+			segments += len(c.Coordinates) - 1
+		}
+	}
+	assert.NotZero(t, segments)
+	var lessThan int
+	for _, c := range minimal.Sudoku.Constraints {
+		if _, ok := c.(constraint.LessThanConstraint); ok {
+			lessThan++
+		}
+	}
+	assert.Equal(t, segments, lessThan)
+}
+
+func TestMinimizeRejectsAmbiguousSudoku(t *testing.T) {
+	// without the 2 at R1C1 the sudoku has several solutions
+	sudok, err := sudokuio.ParseString(strings.Replace(minimizeTestSudoku, "24-", "-4-", 1))
+	require.NoError(t, err)
+	_, err = generate.Minimize(context.Background(), sudokuio.ClassicJSON(*sudok), false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package generate
+
+import (
+	"math/rand"
+	"slices"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+)
+
+// Rules selects the variant constraints a puzzle has on top of the normal
+// sudoku rules.
+type Rules struct {
+	// KillerCages divides the whole grid into killer cages of up to five
+	// cells. A cell whose neighbours all belong to other cages or repeat a
+	// value of its cage forms a cage of one cell.
+	KillerCages bool
+	// Thermometers is the number of thermometers of three to six cells.
+	Thermometers int
+	// AntiKnight requires cells a knight's move apart to have different
+	// values.
+	AntiKnight bool
+}
+
+// placeVariants places the killer cages and thermometers of the rules so that
+// the solution satisfies them. It returns false if there is no room for all
+// thermometers.
+func placeVariants(rng *rand.Rand, rules Rules, coordinates []sudoku.Coordinate, solution map[sudoku.Coordinate]int) ([]sudokuio.JSONConstraint, bool) {
+	constraints := make([]sudokuio.JSONConstraint, 0)
+	if rules.KillerCages {
+		for _, cage := range placeKillerCages(rng, coordinates, solution) {
+			sum := 0
+			for _, coord := range cage {
+				sum += solution[coord]
+			}
+			constraints = append(constraints, sudokuio.KillerCageJSON(cage, sum))
+		}
+	}
+	if rules.Thermometers > 0 {
+		thermometers, ok := placeThermometers(rng, coordinates, solution, rules.Thermometers)
+		if !ok {
+			return nil, false
+		}
+		for _, thermometer := range thermometers {
+			constraints = append(constraints, sudokuio.ThermometerJSON(thermometer))
+		}
+	}
+	return constraints, true
+}
+
+// placeKillerCages divides the coordinates into cages. Every cage grows from a
+// random cell to a random size by adding orthogonal neighbours whose values
+// aren't in the cage yet. Cages that can't grow stay smaller, down to a single
+// cell.
+func placeKillerCages(rng *rand.Rand, coordinates []sudoku.Coordinate, solution map[sudoku.Coordinate]int) [][]sudoku.Coordinate {
+	covered := make(map[sudoku.Coordinate]bool, len(coordinates))
+	cages := make([][]sudoku.Coordinate, 0)
+	for _, i := range rng.Perm(len(coordinates)) {
+		start := coordinates[i]
+		if covered[start] {
+			continue
+		}
+		size := 2 + rng.Intn(4)
+		cage := []sudoku.Coordinate{start}
+		covered[start] = true
+		for len(cage) < size {
+			neighbours := make([]sudoku.Coordinate, 0)
+			for _, coord := range cage {
+				for _, neighbour := range coord.OrthogonalNeighbours() {
+					if _, ok := solution[neighbour]; !ok || covered[neighbour] || slices.Contains(neighbours, neighbour) {
+						continue
+					}
+					if slices.ContainsFunc(cage, func(c sudoku.Coordinate) bool { return solution[c] == solution[neighbour] }) {
+						continue
+					}
+					neighbours = append(neighbours, neighbour)
+				}
+			}
+			if len(neighbours) == 0 {
+				break
+			}
+			next := neighbours[rng.Intn(len(neighbours))]
+			cage = append(cage, next)
+			covered[next] = true
+		}
+		cages = append(cages, inOrder(coordinates, cage))
+	}
+	return cages
+}
+
+// placeThermometers places thermometers that don't share cells. Every
+// thermometer starts at a random bulb and grows to a random length by adding
+// a touching cell with a bigger value. It returns false if it runs out of
+// tries before all thermometers have at least three cells.
+func placeThermometers(rng *rand.Rand, coordinates []sudoku.Coordinate, solution map[sudoku.Coordinate]int, count int) ([][]sudoku.Coordinate, bool) {
+	used := make(map[sudoku.Coordinate]bool)
+	thermometers := make([][]sudoku.Coordinate, 0, count)
+	for tries := 0; len(thermometers) < count; tries++ {
+		if tries == 100*count {
+			return nil, false
+		}
+		bulb := coordinates[rng.Intn(len(coordinates))]
+		if used[bulb] {
+			continue
+		}
+		length := 3 + rng.Intn(4)
+		thermometer := []sudoku.Coordinate{bulb}
+		for len(thermometer) < length {
+			last := thermometer[len(thermometer)-1]
+			next := make([]sudoku.Coordinate, 0, 8)
+			for row := last.Row - 1; row <= last.Row+1; row++ {
+				for col := last.Col - 1; col <= last.Col+1; col++ {
+					coord := sudoku.Coordinate{Row: row, Col: col}
+					value, ok := solution[coord]
+					if !ok || used[coord] || value <= solution[last] {
+						continue
+					}
+					next = append(next, coord)
+				}
+			}
+			if len(next) == 0 {
+				break
+			}
+			thermometer = append(thermometer, next[rng.Intn(len(next))])
+		}
+		if len(thermometer) < 3 {
+			continue
+		}
+		for _, coord := range thermometer {
+			used[coord] = true
+		}
+		thermometers = append(thermometers, thermometer)
+	}
+	return thermometers, true
+}
+
+// inOrder returns the cells in the order of the coordinates.
+func inOrder(coordinates, cells []sudoku.Coordinate) []sudoku.Coordinate {
+	ordered := make([]sudoku.Coordinate, 0, len(cells))
+	for _, coord := range coordinates {
+		if slices.Contains(cells, coord) {
+			ordered = append(ordered, coord)
+		}
+	}
+	return ordered
+}
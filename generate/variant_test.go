@@ -0,0 +1,94 @@
+package generate_test
+
+import (
+	"context"
+	"encoding/json"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/generate"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireRoundTrip checks that the JSON of the puzzle parses to the same
+// sudoku and that it has a unique solution.
+func requireRoundTrip(t *testing.T, puzzle generate.Puzzle) sudokuio.JSONSudoku {
+	description, err := puzzle.JSON()
+	require.NoError(t, err)
+	bytes, err := json.Marshal(description)
+	require.NoError(t, err)
+	parsed, err := sudokuio.ParseJSON(bytes)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, puzzle.Sudoku.Constraints, parsed.Constraints)
+
+	uniqueness, err := backtrack.CheckUniqueness(context.Background(), backtrack.ModePencilMarkMRV, *parsed)
+	require.NoError(t, err)
+	require.True(t, uniqueness.IsUnique())
+	assert.True(t, parsed.IsSolved(sudoku.MapSolution(puzzle.Solution)))
+	return description
+}
+
+func TestVariantKillerCages(t *testing.T) {
+	puzzle, err := generate.Variant(context.Background(), generate.Rules{KillerCages: true}, generate.Options{Seed: 1, Clues: 20})
+	require.NoError(t, err)
+	assert.Len(t, puzzle.Clues, 20)
+	description := requireRoundTrip(t, puzzle)
+
+	// the cages cover every cell once
+	covered := make(map[sudoku.Coordinate]int)
+	for _, c := range description.Constraints {
+		if c.Type != "killerCage" {
+			continue
+		}
+		assert.LessOrEqual(t, len(c.Coordinates), 5)
+		for _, coord := range c.Coordinates {
+			covered[sudoku.Coordinate(coord)]++
+		}
+	}
+	assert.Len(t, covered, 81)
+	for coord, count := range covered {
+		assert.Equal(t, 1, count, coord)
+	}
+}
+
+func TestVariantThermometersAndAntiKnight(t *testing.T) {
+	rules := generate.Rules{Thermometers: 3, AntiKnight: true}
+	puzzle, err := generate.Variant(context.Background(), rules, generate.Options{Seed: 2, Clues: 25})
+	require.NoError(t, err)
+	description := requireRoundTrip(t, puzzle)
+
+	thermometers := 0
+	for _, c := range description.Constraints {
+		switch c.Type {
+		case "thermometer":
+			thermometers++
+			assert.GreaterOrEqual(t, len(c.Coordinates), 3)
+		case "antiKnight", "normalSudokuRules":
+		default:
+			t.Errorf("unexpected constraint %s", c.Type)
+		}
+	}
+	assert.Equal(t, 3, thermometers)
+}
+
+func TestVariantIsReproducible(t *testing.T) {
+	rules := generate.Rules{KillerCages: true, Thermometers: 1}
+	options := generate.Options{Seed: 3, Clues: 30}
+	puzzle1, err := generate.Variant(context.Background(), rules, options)
+	require.NoError(t, err)
+	puzzle2, err := generate.Variant(context.Background(), rules, options)
+	require.NoError(t, err)
+	description1, err := puzzle1.JSON()
+	require.NoError(t, err)
+	description2, err := puzzle2.JSON()
+	require.NoError(t, err)
+	assert.Equal(t, description1, description2)
+}
+
+func TestVariantRejectsNegativeThermometers(t *testing.T) {
+	_, err := generate.Variant(context.Background(), generate.Rules{Thermometers: -1}, generate.Options{})
+	assert.Error(t, err)
+}
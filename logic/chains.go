@@ -0,0 +1,171 @@
+package logic
+
+// maxChainLinks is the maximum number of links of a chain.
+const maxChainLinks = 15
+
+// chainNode is a candidate that is part of a chain.
+type chainNode struct {
+	cell, value int
+}
+
+// chainGraph holds the candidates of the empty cells and the strong links
+// between them. If one end of a strong link is false, the other end is true.
+// Weak links are implied by the grid: two candidates of the same cell or the
+// same value in cells that see each other can't both be true.
+type chainGraph struct {
+	g      *Grid
+	nodes  []chainNode
+	ids    map[chainNode]int
+	strong [][]int
+}
+
+func newChainGraph(g *Grid) *chainGraph {
+	c := &chainGraph{g: g, ids: make(map[chainNode]int)}
+	for i := range g.sudok.Coordinates {
+		if g.placed[i] {
+			continue
+		}
+		for _, value := range g.candidates[i].Values() {
+			c.ids[chainNode{cell: i, value: value}] = len(c.nodes)
+			c.nodes = append(c.nodes, chainNode{cell: i, value: value})
+		}
+	}
+	c.strong = make([][]int, len(c.nodes))
+	link := func(a, b chainNode) {
+		c.strong[c.ids[a]] = append(c.strong[c.ids[a]], c.ids[b])
+		c.strong[c.ids[b]] = append(c.strong[c.ids[b]], c.ids[a])
+	}
+	for i := range g.sudok.Coordinates {
+		if !g.placed[i] && g.candidates[i].Len() == 2 {
+			values := g.candidates[i].Values()
+			link(chainNode{cell: i, value: values[0]}, chainNode{cell: i, value: values[1]})
+		}
+	}
+	for _, value := range g.sudok.PossibleValues {
+		for i, others := range conjugates(g, value) {
+			for _, j := range others {
+				if i < j {
+					link(chainNode{cell: i, value: value}, chainNode{cell: j, value: value})
+				}
+			}
+		}
+	}
+	return c
+}
+
+func (c *chainGraph) weaklyLinked(a, b chainNode) bool {
+	if a == b {
+		return false
+	}
+	return a.cell == b.cell || a.value == b.value && c.g.sees[a.cell][b.cell]
+}
+
+// weakNeighbours returns the candidates that are false if the node is true.
+func (c *chainGraph) weakNeighbours(id int) []int {
+	node := c.nodes[id]
+	neighbours := make([]int, 0)
+	for _, value := range c.g.candidates[node.cell].Values() {
+		if value != node.value {
+			neighbours = append(neighbours, c.ids[chainNode{cell: node.cell, value: value}])
+		}
+	}
+	for _, peer := range c.g.peers[node.cell] {
+		if other, ok := c.ids[chainNode{cell: peer, value: node.value}]; ok {
+			neighbours = append(neighbours, other)
+		}
+	}
+	return neighbours
+}
+
+// eliminations returns the candidates that can't be true if one of the ends
+// is true.
+func (c *chainGraph) eliminations(start, end int) []Candidate {
+	var eliminations []Candidate
+	for _, node := range c.nodes {
+		if c.weaklyLinked(node, c.nodes[start]) && c.weaklyLinked(node, c.nodes[end]) {
+			eliminations = append(eliminations, Candidate{Coordinate: c.g.sudok.Coordinates[node.cell], Value: node.value})
+		}
+	}
+	return eliminations
+}
+
+// shortestChainFrom searches the shortest alternating inference chain that
+// starts with a strong link at the node and removes candidates. It searches
+// breadth first over the node and whether it is true or false when the start
+// is false. It returns the nodes of the chain or nil.
+func (c *chainGraph) shortestChainFrom(start, maxLinks int) ([]int, []Candidate) {
+	// state 2*id is the node being false, 2*id+1 the node being true
+	parent := make([]int, 2*len(c.nodes))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[2*start] = 2 * start
+	queue := []int{2 * start}
+	for links := 0; links < maxLinks && len(queue) > 0; links++ {
+		next := make([]int, 0)
+		for _, state := range queue {
+			id, isTrue := state/2, state%2 == 1
+			neighbours := c.strong[id]
+			if isTrue {
+				neighbours = c.weakNeighbours(id)
+			}
+			for _, neighbour := range neighbours {
+				nextState := 2 * neighbour
+				if !isTrue {
+					nextState++
+				}
+				if parent[nextState] >= 0 {
+					continue
+				}
+				parent[nextState] = state
+				next = append(next, nextState)
+				if !isTrue {
+					// the chain ends with a strong link, so the start or the
+					// neighbour is true
+					if eliminations := c.eliminations(start, neighbour); len(eliminations) > 0 {
+						chain := []int{neighbour}
+						for s := state; s != 2*start; s = parent[s] {
+							chain = append(chain, s/2)
+						}
+						chain = append(chain, start)
+						for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+							chain[i], chain[j] = chain[j], chain[i]
+						}
+						return chain, eliminations
+					}
+				}
+			}
+		}
+		queue = next
+	}
+	return nil, nil
+}
+
+// findChain looks for the shortest alternating inference chain that removes
+// candidates. The chain alternates between strong and weak links and starts
+// and ends with a strong link, so one of its ends is true. Candidates that
+// can't be true together with either end are false.
+func findChain(g *Grid) (Step, bool, error) {
+	c := newChainGraph(g)
+	var best []int
+	var bestEliminations []Candidate
+	for start := range c.nodes {
+		maxLinks := maxChainLinks
+		if best != nil {
+			maxLinks = len(best) - 2
+		}
+		chain, eliminations := c.shortestChainFrom(start, maxLinks)
+		if chain != nil {
+			best, bestEliminations = chain, eliminations
+		}
+	}
+	if best == nil {
+		return Step{}, false, nil
+	}
+	step := Step{Technique: TechniqueChain, Eliminations: bestEliminations}
+	for _, id := range best {
+		step.Values = append(step.Values, c.nodes[id].value)
+		step.Cells = append(step.Cells, g.sudok.Coordinates[c.nodes[id].cell])
+	}
+	return step, true, nil
+}
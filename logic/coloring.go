@@ -0,0 +1,115 @@
+package logic
+
+// conjugates returns for every cell the cells it forms a conjugate pair with
+// for the value, i.e. the cells that are the only other place for the value
+// in a house.
+func conjugates(g *Grid, value int) [][]int {
+	links := make([][]int, len(g.sudok.Coordinates))
+	for _, house := range g.houses {
+		if g.isPlacedIn(house, value) {
+			continue
+		}
+		cells := g.cellsWith(house, value)
+		if len(cells) != 2 {
+			continue
+		}
+		links[cells[0]] = append(links[cells[0]], cells[1])
+		links[cells[1]] = append(links[cells[1]], cells[0])
+	}
+	return links
+}
+
+// findSimpleColoring colors the cells of a value that are connected by
+// conjugate pairs in two alternating colors. Either all cells of one color
+// have the value or all cells of the other color. If two cells of the same
+// color see each other, that color is false (color wrap). Otherwise cells
+// that see both colors can't have the value (color trap).
+func findSimpleColoring(g *Grid) (Step, bool, error) {
+	for _, value := range g.sudok.PossibleValues {
+		links := conjugates(g, value)
+		color := make([]int, len(g.sudok.Coordinates))
+		for start := range g.sudok.Coordinates {
+			if color[start] != 0 || len(links[start]) == 0 {
+				continue
+			}
+			// colors are 1 and 2, 0 means not colored yet
+			component := []int{start}
+			color[start] = 1
+			for k := 0; k < len(component); k++ {
+				i := component[k]
+				for _, j := range links[i] {
+					if color[j] == 0 {
+						color[j] = 3 - color[i]
+						component = append(component, j)
+					}
+				}
+			}
+			if len(component) < 3 {
+				// a single conjugate pair doesn't remove anything that isn't
+				// covered by simpler techniques
+				continue
+			}
+			if step, ok := colorWrap(g, value, component, color); ok {
+				return step, true, nil
+			}
+			if step, ok := colorTrap(g, value, component, color); ok {
+				return step, true, nil
+			}
+		}
+	}
+	return Step{}, false, nil
+}
+
+func colorWrap(g *Grid, value int, component []int, color []int) (Step, bool) {
+	for _, i := range component {
+		for _, j := range component {
+			if i == j || color[i] != color[j] || !g.sees[i][j] {
+				continue
+			}
+			falseCells := make([]int, 0, len(component))
+			for _, k := range component {
+				if color[k] == color[i] {
+					falseCells = append(falseCells, k)
+				}
+			}
+			return Step{
+				Technique:    TechniqueSimpleColoring,
+				Values:       []int{value},
+				Cells:        g.coordinates(component),
+				Eliminations: g.eliminations(falseCells, newValueSet(value)),
+			}, true
+		}
+	}
+	return Step{}, false
+}
+
+func colorTrap(g *Grid, value int, component []int, color []int) (Step, bool) {
+	inComponent := make(map[int]bool, len(component))
+	for _, i := range component {
+		inComponent[i] = true
+	}
+	trapped := make([]int, 0)
+	for i := range g.sudok.Coordinates {
+		if inComponent[i] || g.placed[i] || !g.candidates[i].Contains(value) {
+			continue
+		}
+		seesColor := [3]bool{}
+		for _, j := range component {
+			if g.sees[i][j] {
+				seesColor[color[j]] = true
+			}
+		}
+		if seesColor[1] && seesColor[2] {
+			trapped = append(trapped, i)
+		}
+	}
+	if len(trapped) == 0 {
+		return Step{}, false
+	}
+	return Step{
+		Technique:    TechniqueSimpleColoring,
+		Values:       []int{value},
+		Cells:        g.coordinates(component),
+		Eliminations: g.eliminations(trapped, newValueSet(value)),
+	}, true
+}
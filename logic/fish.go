@@ -0,0 +1,81 @@
+package logic
+
+import "slices"
+
+// findFish returns a finder for a value that can only be in n cover lines
+// within n base lines. One of the cells in the base lines has the value in
+// every cover line, so no other cell of the cover lines can have it. Rows are
+// tried as base lines first, then columns.
+func findFish(technique Technique, n int) func(g *Grid) (Step, bool, error) {
+	return func(g *Grid) (Step, bool, error) {
+		for _, value := range g.sudok.PossibleValues {
+			if step, ok := findFishIn(g, technique, n, value, g.rows, g.columns, g.columnOf); ok {
+				return step, true, nil
+			}
+			if step, ok := findFishIn(g, technique, n, value, g.columns, g.rows, g.rowOf); ok {
+				return step, true, nil
+			}
+		}
+		return Step{}, false, nil
+	}
+}
+
+func findFishIn(g *Grid, technique Technique, n, value int, base, cover [][]int, coverOf []int) (Step, bool) {
+	// only lines where the value is in at most n cells that all lie in cover
+	// lines can be base lines
+	candidates := make([]int, 0, len(base))
+	for position, line := range base {
+		if g.isPlacedIn(line, value) {
+			continue
+		}
+		cells := g.cellsWith(line, value)
+		if len(cells) < 2 || len(cells) > n {
+			continue
+		}
+		inCover := true
+		for _, i := range cells {
+			inCover = inCover && coverOf[i] >= 0
+		}
+		if inCover {
+			candidates = append(candidates, position)
+		}
+	}
+
+	var step Step
+	found := combinations(candidates, n, func(positions []int) bool {
+		var baseCells []int
+		var coverLines []int
+		for _, position := range positions {
+			for _, i := range g.cellsWith(base[position], value) {
+				baseCells = append(baseCells, i)
+				if !slices.Contains(coverLines, coverOf[i]) {
+					coverLines = append(coverLines, coverOf[i])
+				}
+			}
+		}
+		if len(coverLines) != n {
+			return false
+		}
+		slices.Sort(coverLines)
+		rest := make([]int, 0)
+		for _, line := range coverLines {
+			for _, i := range cover[line] {
+				if !slices.Contains(baseCells, i) {
+					rest = append(rest, i)
+				}
+			}
+		}
+		eliminations := g.eliminations(rest, newValueSet(value))
+		if len(eliminations) == 0 {
+			return false
+		}
+		step = Step{
+			Technique:    technique,
+			Values:       []int{value},
+			Cells:        g.coordinates(baseCells),
+			Eliminations: eliminations,
+		}
+		return true
+	})
+	return step, found
+}
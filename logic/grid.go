@@ -0,0 +1,408 @@
+package logic
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+)
+
+// valueSet is a set of values stored as a bitmask where bit v is set if the
+// value v is in the set. It can only hold the values 0 to 63.
+type valueSet uint64
+
+func newValueSet(values ...int) valueSet {
+	var set valueSet
+	for _, value := range values {
+		set |= 1 << uint(value)
+	}
+	return set
+}
+
+func (s valueSet) Contains(value int) bool {
+	return value >= 0 && value <= 63 && s&(1<<uint(value)) != 0
+}
+
+func (s valueSet) Len() int {
+	return bits.OnesCount64(uint64(s))
+}
+
+// Values returns the values in the set in ascending order.
+func (s valueSet) Values() []int {
+	values := make([]int, 0, s.Len())
+	for rest := s; rest != 0; rest &= rest - 1 {
+		values = append(values, bits.TrailingZeros64(uint64(rest)))
+	}
+	return values
+}
+
+// structure holds the parts of a sudoku that don't change while it is
+// solved. Cells are referred to by their index in the coordinates of the
+// sudoku.
+type structure struct {
+	sudok sudoku.Sudoku
+	index map[sudoku.Coordinate]int
+	// groups holds the cells of every no repeat constraint
+	groups [][]int
+	// houses are the groups that contain every possible value exactly once
+	houses [][]int
+	// rows and columns are the houses that lie in a single row or column
+	rows, columns [][]int
+	// rowOf and columnOf hold the position of the row and column of every
+	// cell in rows and columns or -1
+	rowOf, columnOf []int
+	// peers holds for every cell the cells that share a group with it
+	peers [][]int
+	sees  [][]bool
+	// others holds the constraints that are neither no repeat nor fixed value
+	// constraints
+	others []sudoku.Constraint
+}
+
+func newStructure(sudok sudoku.Sudoku) (*structure, error) {
+	if sudok.RegionCount > 0 {
+		return nil, fmt.Errorf("sudokus with unknown regions can't be solved logically")
+	}
+	for _, value := range sudok.PossibleValues {
+		if value < 0 || value > 63 {
+			return nil, fmt.Errorf("possible value %d is not between 0 and 63", value)
+		}
+	}
+	s := &structure{
+		sudok: sudok,
+		index: make(map[sudoku.Coordinate]int, len(sudok.Coordinates)),
+		peers: make([][]int, len(sudok.Coordinates)),
+		sees:  make([][]bool, len(sudok.Coordinates)),
+	}
+	for i, coord := range sudok.Coordinates {
+		s.index[coord] = i
+		s.sees[i] = make([]bool, len(sudok.Coordinates))
+	}
+	for _, constr := range sudok.Constraints {
+		var coordinates []sudoku.Coordinate
+		switch constr := constr.(type) {
+		case constraint.NoRepeatConstraint:
+			coordinates = constr.Coordinates
+		case *constraint.NoRepeatConstraint:
+			coordinates = constr.Coordinates
+		case constraint.FixedValueConstraint, *constraint.FixedValueConstraint:
+			continue
+		default:
+			s.others = append(s.others, constr)
+			continue
+		}
+		group := make([]int, 0, len(coordinates))
+		for _, coord := range coordinates {
+			i, ok := s.index[coord]
+			if !ok {
+				return nil, fmt.Errorf("coordinate %v is not part of the sudoku", coord)
+			}
+			group = append(group, i)
+		}
+		s.groups = append(s.groups, group)
+		for _, i := range group {
+			for _, j := range group {
+				if i != j && !s.sees[i][j] {
+					s.sees[i][j] = true
+					s.peers[i] = append(s.peers[i], j)
+				}
+			}
+		}
+		if len(group) != len(sudok.PossibleValues) {
+			continue
+		}
+		s.houses = append(s.houses, group)
+		sameRow, sameCol := true, true
+		for _, coord := range coordinates {
+			sameRow = sameRow && coord.Row == coordinates[0].Row
+			sameCol = sameCol && coord.Col == coordinates[0].Col
+		}
+		if sameRow {
+			s.rows = append(s.rows, group)
+		}
+		if sameCol {
+			s.columns = append(s.columns, group)
+		}
+	}
+	for i := range s.peers {
+		slices.Sort(s.peers[i])
+	}
+	s.rowOf, s.columnOf = lineOf(s.rows, len(sudok.Coordinates)), lineOf(s.columns, len(sudok.Coordinates))
+	return s, nil
+}
+
+// lineOf returns the position of the line of every cell or -1.
+func lineOf(lines [][]int, cells int) []int {
+	positions := make([]int, cells)
+	for i := range positions {
+		positions[i] = -1
+	}
+	for position, line := range lines {
+		for _, i := range line {
+			positions[i] = position
+		}
+	}
+	return positions
+}
+
+// Grid holds the pencil marks of a sudoku that is solved by hand: the values
+// that were placed and the candidates that are left for every other cell.
+// Placing a value removes it from the candidates of all cells that share a no
+// repeat constraint with it, like a player would do without thinking about
+// it. All other deductions are made by steps.
+type Grid struct {
+	*structure
+	placed     []bool
+	values     []int
+	candidates []valueSet
+}
+
+var _ sudoku.Domains = (*Grid)(nil)
+
+// NewGrid creates the grid of the sudoku with all givens placed.
+func NewGrid(sudok sudoku.Sudoku) (*Grid, error) {
+	s, err := newStructure(sudok)
+	if err != nil {
+		return nil, err
+	}
+	g := &Grid{
+		structure:  s,
+		placed:     make([]bool, len(sudok.Coordinates)),
+		values:     make([]int, len(sudok.Coordinates)),
+		candidates: make([]valueSet, len(sudok.Coordinates)),
+	}
+	all := newValueSet(sudok.PossibleValues...)
+	for i := range g.candidates {
+		g.candidates[i] = all
+	}
+	for _, constr := range sudok.Constraints {
+		var fvc constraint.FixedValueConstraint
+		switch constr := constr.(type) {
+		case constraint.FixedValueConstraint:
+			fvc = constr
+		case *constraint.FixedValueConstraint:
+			fvc = *constr
+		default:
+			continue
+		}
+		if err := g.Place(fvc.Coordinate, fvc.Value); err != nil {
+			return nil, fmt.Errorf("place given: %w", err)
+		}
+	}
+	return g, nil
+}
+
+// Sudoku returns the sudoku of the grid.
+func (g *Grid) Sudoku() sudoku.Sudoku {
+	return g.sudok
+}
+
+// coordinates returns the coordinates of the cells.
+func (s *structure) coordinates(cells []int) []sudoku.Coordinate {
+	coordinates := make([]sudoku.Coordinate, 0, len(cells))
+	for _, i := range cells {
+		coordinates = append(coordinates, s.sudok.Coordinates[i])
+	}
+	return coordinates
+}
+
+// eliminations returns the values of the set that are still candidates of
+// the empty cells.
+func (g *Grid) eliminations(cells []int, values valueSet) []Candidate {
+	var eliminations []Candidate
+	for _, i := range cells {
+		if g.placed[i] {
+			continue
+		}
+		for _, value := range (g.candidates[i] & values).Values() {
+			eliminations = append(eliminations, Candidate{Coordinate: g.sudok.Coordinates[i], Value: value})
+		}
+	}
+	return eliminations
+}
+
+// cellsWith returns the empty cells of the group that have the value as
+// candidate.
+func (g *Grid) cellsWith(group []int, value int) []int {
+	cells := make([]int, 0, len(group))
+	for _, i := range group {
+		if !g.placed[i] && g.candidates[i].Contains(value) {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+// isPlacedIn returns true if the value is placed in a cell of the group.
+func (g *Grid) isPlacedIn(group []int, value int) bool {
+	for _, i := range group {
+		if g.placed[i] && g.values[i] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Grid) cell(coordinate sudoku.Coordinate) (int, error) {
+	i, ok := g.index[coordinate]
+	if !ok {
+		return 0, fmt.Errorf("coordinate %v is not part of the sudoku", coordinate)
+	}
+	return i, nil
+}
+
+// Place puts the value into the cell and removes it from the candidates of
+// its peers. It returns an error if the value is not a candidate of the cell
+// or a peer has no candidates left.
+func (g *Grid) Place(coordinate sudoku.Coordinate, value int) error {
+	i, err := g.cell(coordinate)
+	if err != nil {
+		return err
+	}
+	if g.placed[i] {
+		if g.values[i] != value {
+			return fmt.Errorf("coordinate %v already has the value %d", coordinate, g.values[i])
+		}
+		return nil
+	}
+	if !g.candidates[i].Contains(value) {
+		return fmt.Errorf("value %d is not a candidate of %v", value, coordinate)
+	}
+	g.placed[i] = true
+	g.values[i] = value
+	g.candidates[i] = newValueSet(value)
+	for _, peer := range g.peers[i] {
+		if g.placed[peer] {
+			if g.values[peer] == value {
+				return fmt.Errorf("value %d is placed twice at %v and %v", value, coordinate, g.sudok.Coordinates[peer])
+			}
+			continue
+		}
+		if err := g.eliminate(peer, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Eliminate removes the value from the candidates of the cell. It returns an
+// error if the value is placed in the cell or no candidates are left.
+func (g *Grid) Eliminate(coordinate sudoku.Coordinate, value int) error {
+	i, err := g.cell(coordinate)
+	if err != nil {
+		return err
+	}
+	if g.placed[i] && g.values[i] == value {
+		return fmt.Errorf("value %d is placed at %v", value, coordinate)
+	}
+	return g.eliminate(i, value)
+}
+
+func (g *Grid) eliminate(i int, value int) error {
+	g.candidates[i] &^= newValueSet(value)
+	if g.candidates[i] == 0 {
+		return fmt.Errorf("coordinate %v has no candidates left", g.sudok.Coordinates[i])
+	}
+	return nil
+}
+
+// SetCandidates replaces the candidates of an empty cell, e.g. with the
+// pencil marks of a player. Values that are not possible in the sudoku are
+// ignored.
+func (g *Grid) SetCandidates(coordinate sudoku.Coordinate, values []int) error {
+	i, err := g.cell(coordinate)
+	if err != nil {
+		return err
+	}
+	if g.placed[i] {
+		return fmt.Errorf("coordinate %v already has the value %d", coordinate, g.values[i])
+	}
+	candidates := newValueSet(values...) & newValueSet(g.sudok.PossibleValues...)
+	if candidates == 0 {
+		return fmt.Errorf("coordinate %v has no candidates", coordinate)
+	}
+	g.candidates[i] = candidates
+	return nil
+}
+
+// Apply places and eliminates the values of the step.
+func (g *Grid) Apply(step Step) error {
+	for _, placement := range step.Placements {
+		if err := g.Place(placement.Coordinate, placement.Value); err != nil {
+			return fmt.Errorf("apply %s: %w", step.Technique, err)
+		}
+	}
+	for _, elimination := range step.Eliminations {
+		if err := g.Eliminate(elimination.Coordinate, elimination.Value); err != nil {
+			return fmt.Errorf("apply %s: %w", step.Technique, err)
+		}
+	}
+	return nil
+}
+
+// Copy returns a grid with the same values and candidates that can be changed
+// independently.
+func (g *Grid) Copy() *Grid {
+	return &Grid{
+		structure:  g.structure,
+		placed:     slices.Clone(g.placed),
+		values:     slices.Clone(g.values),
+		candidates: slices.Clone(g.candidates),
+	}
+}
+
+// IsSolved returns true if every cell has a value.
+func (g *Grid) IsSolved() bool {
+	return !slices.Contains(g.placed, false)
+}
+
+func (g *Grid) Get(coordinate sudoku.Coordinate) (int, bool) {
+	i, ok := g.index[coordinate]
+	if !ok || !g.placed[i] {
+		return 0, false
+	}
+	return g.values[i], true
+}
+
+func (g *Grid) Candidates(coordinate sudoku.Coordinate) []int {
+	i, ok := g.index[coordinate]
+	if !ok {
+		return nil
+	}
+	return g.candidates[i].Values()
+}
+
+func (g *Grid) CanBe(coordinate sudoku.Coordinate, value int) bool {
+	i, ok := g.index[coordinate]
+	return ok && g.candidates[i].Contains(value)
+}
+
+func (g *Grid) Bounds(coordinate sudoku.Coordinate) (int, int) {
+	candidates := g.Candidates(coordinate)
+	if len(candidates) == 0 {
+		return 0, 0
+	}
+	return candidates[0], candidates[len(candidates)-1]
+}
+
+// Restrict keeps the candidates of the cell for which keep returns true. It
+// never places a value, even if a single candidate is left.
+func (g *Grid) Restrict(coordinate sudoku.Coordinate, keep func(int) bool) error {
+	i, err := g.cell(coordinate)
+	if err != nil {
+		return err
+	}
+	for _, value := range g.candidates[i].Values() {
+		if keep(value) {
+			continue
+		}
+		if g.placed[i] {
+			return fmt.Errorf("value %d is placed at %v", value, coordinate)
+		}
+		if err := g.eliminate(i, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/sudoku"
+)
+
+// Progress is what a player filled into a sudoku so far.
+type Progress struct {
+	// Values holds the values the player placed, without the givens.
+	Values map[sudoku.Coordinate]int
+	// PencilMarks holds the candidates the player noted for empty cells.
+	// Empty cells without pencil marks can have every value that no placed
+	// value rules out.
+	PencilMarks map[sudoku.Coordinate][]int
+}
+
+// MistakeKind tells what is wrong about a cell.
+type MistakeKind string
+
+const (
+	// MistakeWrongValue is a placed value that the cell can't have.
+	MistakeWrongValue MistakeKind = "wrong value"
+	// MistakeMissingCandidate is a cell whose pencil marks miss the value it
+	// has in the solution.
+	MistakeMissingCandidate MistakeKind = "missing candidate"
+)
+
+// Mistake is something in the progress of a player that contradicts the
+// solution.
+type Mistake struct {
+	Kind       MistakeKind
+	Coordinate sudoku.Coordinate
+	// Value is the wrong value that was placed. It is 0 for a missing
+	// candidate, so that the mistake doesn't give away the right value.
+	Value int
+}
+
+// Hint is the next thing a player can do: either fix mistakes or make the
+// simplest deduction.
+type Hint struct {
+	// Mistakes holds the values that were placed but are wrong and the cells
+	// whose pencil marks miss their right value. The hint has no step if
+	// there are mistakes.
+	Mistakes []Mistake
+	// Step is the simplest deduction that places or removes a value.
+	Step Step
+	// Explanation describes the mistakes or the step in words.
+	Explanation string
+	// Cells are all cells the hint is about in the order of the coordinates
+	// of the sudoku.
+	Cells []sudoku.Coordinate
+}
+
+// NextHint returns the simplest deduction that can be made from the progress
+// of a player. If the sudoku has a unique solution, the progress is checked
+// against it first and the hint points out the mistakes instead. It returns
+// false if no technique applies and an error if the sudoku has no solution,
+// the progress breaks a no repeat constraint or the context is cancelled.
+func NextHint(ctx context.Context, sudok sudoku.Sudoku, progress Progress) (Hint, bool, error) {
+	uniqueness, err := backtrack.CheckUniqueness(ctx, backtrack.ModePencilMarkMRV, sudok)
+	if err != nil {
+		return Hint{}, false, fmt.Errorf("check uniqueness: %w", err)
+	}
+	if !uniqueness.HasSolution() {
+		return Hint{}, false, fmt.Errorf("sudoku has no solution")
+	}
+	if uniqueness.IsUnique() {
+		if mistakes, explanation := findMistakes(sudok, progress, uniqueness.Solutions[0]); len(mistakes) > 0 {
+			cells := make([]sudoku.Coordinate, 0, len(mistakes))
+			for _, mistake := range mistakes {
+				cells = append(cells, mistake.Coordinate)
+			}
+			return Hint{
+				Mistakes:    mistakes,
+				Explanation: explanation,
+				Cells:       affectedCells(sudok, cells, nil),
+			}, true, nil
+		}
+	}
+
+	g, err := progressGrid(sudok, progress)
+	if err != nil {
+		return Hint{}, false, err
+	}
+	step, ok, err := NextStep(g)
+	if err != nil || !ok {
+		return Hint{}, false, err
+	}
+	return Hint{
+		Step:        step,
+		Explanation: Explain(step),
+		Cells:       affectedCells(sudok, step.Cells, append(slices.Clip(step.Placements), step.Eliminations...)),
+	}, true, nil
+}
+
+// progressGrid creates the grid of the sudoku with the values and pencil
+// marks of the player.
+func progressGrid(sudok sudoku.Sudoku, progress Progress) (*Grid, error) {
+	g, err := NewGrid(sudok)
+	if err != nil {
+		return nil, fmt.Errorf("create grid: %w", err)
+	}
+	for _, coord := range sudok.Coordinates {
+		value, ok := progress.Values[coord]
+		if !ok {
+			continue
+		}
+		if err := g.Place(coord, value); err != nil {
+			return nil, fmt.Errorf("place %d at %s: %w", value, cellName(coord), err)
+		}
+	}
+	for _, coord := range sudok.Coordinates {
+		marks, ok := progress.PencilMarks[coord]
+		if !ok {
+			continue
+		}
+		if _, placed := g.Get(coord); placed {
+			continue
+		}
+		// placed values can rule out pencil marks the player didn't update
+		if err := g.Restrict(coord, func(value int) bool { return slices.Contains(marks, value) }); err != nil {
+			return nil, fmt.Errorf("pencil marks of %s: %w", cellName(coord), err)
+		}
+	}
+	return g, nil
+}
+
+// findMistakes compares the progress with the solution and explains the
+// mistakes without giving away the right values.
+func findMistakes(sudok sudoku.Sudoku, progress Progress, solution sudoku.Solution) ([]Mistake, string) {
+	var mistakes []Mistake
+	var sentences []string
+	for _, coord := range sudok.Coordinates {
+		right, _ := solution.Get(coord)
+		if value, ok := progress.Values[coord]; ok {
+			if value != right {
+				mistakes = append(mistakes, Mistake{Kind: MistakeWrongValue, Coordinate: coord, Value: value})
+				sentences = append(sentences, fmt.Sprintf("%s can't be %d.", cellName(coord), value))
+			}
+			continue
+		}
+		if marks, ok := progress.PencilMarks[coord]; ok && !slices.Contains(marks, right) {
+			mistakes = append(mistakes, Mistake{Kind: MistakeMissingCandidate, Coordinate: coord})
+			sentences = append(sentences, fmt.Sprintf("The pencil marks of %s miss a value it can have.", cellName(coord)))
+		}
+	}
+	return mistakes, strings.Join(sentences, " ")
+}
+
+// affectedCells returns the cells and the cells of the candidates without
+// duplicates in the order of the coordinates of the sudoku.
+func affectedCells(sudok sudoku.Sudoku, cells []sudoku.Coordinate, candidates []Candidate) []sudoku.Coordinate {
+	affected := make(map[sudoku.Coordinate]bool, len(cells)+len(candidates))
+	for _, coord := range cells {
+		affected[coord] = true
+	}
+	for _, candidate := range candidates {
+		affected[candidate.Coordinate] = true
+	}
+	ordered := make([]sudoku.Coordinate, 0, len(affected))
+	for _, coord := range sudok.Coordinates {
+		if affected[coord] {
+			ordered = append(ordered, coord)
+		}
+	}
+	return ordered
+}
+
+// houseName names a group of cells: "row 1", "column 2" or "the group from
+// R1C1 to R3C3".
+func houseName(cells []sudoku.Coordinate) string {
+	sameRow, sameCol := true, true
+	for _, coord := range cells {
+		sameRow = sameRow && coord.Row == cells[0].Row
+		sameCol = sameCol && coord.Col == cells[0].Col
+	}
+	switch {
+	case sameRow:
+		return fmt.Sprintf("row %d", cells[0].Row)
+	case sameCol:
+		return fmt.Sprintf("column %d", cells[0].Col)
+	default:
+		return fmt.Sprintf("the group from %s to %s", cellName(cells[0]), cellName(cells[len(cells)-1]))
+	}
+}
+
+// valueList joins values with commas and "and", e.g. "1, 4 and 7".
+func valueList(values []int) string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, strconv.Itoa(value))
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// Explain describes the reasoning of a step in words, followed by what it
+// places or removes.
+func Explain(step Step) string {
+	var reason string
+	switch step.Technique {
+	case TechniqueFullHouse:
+		reason = fmt.Sprintf("%s is the only value missing in %s.", valueList(step.Values), houseName(step.Cells))
+	case TechniqueHiddenSingle:
+		reason = fmt.Sprintf("%s has only one place left in %s.", valueList(step.Values), houseName(step.Cells))
+	case TechniqueNakedSingle:
+		reason = fmt.Sprintf("%s has no other candidate left.", cellNames(step.Cells))
+	case TechniqueConstraint:
+		reason = fmt.Sprintf("The constraint on %s rules out some values on its own.", cellNames(step.Cells))
+	case TechniqueLockedCandidates:
+		reason = fmt.Sprintf("%s can only be in %s within one house, so it can't be anywhere else in the other house of these cells.",
+			valueList(step.Values), cellNames(step.Cells))
+	case TechniqueNakedPair, TechniqueNakedTriple, TechniqueNakedQuad:
+		reason = fmt.Sprintf("%s can only have the values %s, so no other cell they all see can have them.",
+			cellNames(step.Cells), valueList(step.Values))
+	case TechniqueHiddenPair, TechniqueHiddenTriple, TechniqueHiddenQuad:
+		reason = fmt.Sprintf("%s can only go in %s within their house, so these cells can't have any other value.",
+			valueList(step.Values), cellNames(step.Cells))
+	case TechniqueXWing, TechniqueSwordfish, TechniqueJellyfish:
+		lines := map[Technique]int{TechniqueXWing: 2, TechniqueSwordfish: 3, TechniqueJellyfish: 4}[step.Technique]
+		reason = fmt.Sprintf("In %d parallel lines %s can only be in %s, which lie in %d crossing lines, so these cells hold it in each crossing line.",
+			lines, valueList(step.Values), cellNames(step.Cells), lines)
+	case TechniqueXYWing:
+		reason = fmt.Sprintf("Whatever value %s has, one of %s has the value they share.",
+			cellName(step.Cells[0]), cellNames(step.Cells[1:]))
+	case TechniqueSimpleColoring:
+		reason = fmt.Sprintf("%s is either in every other one of %s or in the rest, because they are linked by houses with only two places for it.",
+			valueList(step.Values), cellNames(step.Cells))
+	case TechniqueChain:
+		reason = fmt.Sprintf("If %d at %s is wrong, the chain forces %d at %s, so one of them is right.",
+			step.Values[0], cellName(step.Cells[0]), step.Values[len(step.Values)-1], cellName(step.Cells[len(step.Cells)-1]))
+	}
+
+	effects := make([]string, 0, len(step.Placements)+1)
+	for _, placement := range step.Placements {
+		effects = append(effects, fmt.Sprintf("place %d at %s", placement.Value, cellName(placement.Coordinate)))
+	}
+	if len(step.Eliminations) > 0 {
+		removed := make([]string, 0, len(step.Eliminations))
+		for _, elimination := range step.Eliminations {
+			removed = append(removed, fmt.Sprintf("%d from %s", elimination.Value, cellName(elimination.Coordinate)))
+		}
+		effects = append(effects, "remove "+strings.Join(removed, ", "))
+	}
+	if len(effects) == 0 {
+		return reason
+	}
+	return reason + " So " + strings.Join(effects, " and ") + "."
+}
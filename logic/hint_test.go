@@ -0,0 +1,99 @@
+package logic_test
+
+import (
+	"context"
+	"sudoku-solver/logic"
+	"sudoku-solver/sudoku"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const classicSudoku = "24.....86..3......1....25..59..1...2..7...3..8...4..97..58....3......6..32.....19"
+
+const classicSolution = "249135786753468921186972534594713862617289345832546197465891273971324658328657419"
+
+func coordinate(i int) sudoku.Coordinate {
+	return sudoku.Coordinate{Row: i/9 + 1, Col: i%9 + 1}
+}
+
+func TestNextHintWithoutProgress(t *testing.T) {
+	sudok := parseLine(t, classicSudoku)
+	hint, ok, err := logic.NextHint(context.Background(), sudok, logic.Progress{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Empty(t, hint.Mistakes)
+	assert.Equal(t, logic.TechniqueHiddenSingle, hint.Step.Technique)
+	require.Len(t, hint.Step.Placements, 1)
+	placement := hint.Step.Placements[0]
+	assert.Contains(t, hint.Cells, placement.Coordinate)
+	assert.Contains(t, hint.Explanation, "has only one place left in")
+	i := (placement.Coordinate.Row-1)*9 + placement.Coordinate.Col - 1
+	assert.Equal(t, int(classicSolution[i]-'0'), placement.Value)
+}
+
+func TestNextHintFindsFullHouse(t *testing.T) {
+	// everything but R1C7 is filled in
+	sudok := parseLine(t, classicSudoku)
+	progress := logic.Progress{Values: make(map[sudoku.Coordinate]int)}
+	for i := 9; i < 81; i++ {
+		progress.Values[coordinate(i)] = int(classicSolution[i] - '0')
+	}
+	for i := 2; i < 6; i++ {
+		progress.Values[coordinate(i)] = int(classicSolution[i] - '0')
+	}
+	hint, ok, err := logic.NextHint(context.Background(), sudok, progress)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, logic.TechniqueFullHouse, hint.Step.Technique)
+	assert.Equal(t, "7 is the only value missing in row 1. So place 7 at R1C7.", hint.Explanation)
+}
+
+func TestNextHintPointsOutMistakes(t *testing.T) {
+	sudok := parseLine(t, classicSudoku)
+	progress := logic.Progress{
+		// R1C3 is a 9
+		Values: map[sudoku.Coordinate]int{{Row: 1, Col: 3}: 7},
+		// R1C4 is a 1
+		PencilMarks: map[sudoku.Coordinate][]int{{Row: 1, Col: 4}: {3, 5}},
+	}
+	hint, ok, err := logic.NextHint(context.Background(), sudok, progress)
+	require.NoError(t, err)
+	require.True(t, ok)
+	// the missing 1 of R1C4 is not given away
+	assert.Equal(t, []logic.Mistake{
+		{Kind: logic.MistakeWrongValue, Coordinate: sudoku.Coordinate{Row: 1, Col: 3}, Value: 7},
+		{Kind: logic.MistakeMissingCandidate, Coordinate: sudoku.Coordinate{Row: 1, Col: 4}},
+	}, hint.Mistakes)
+	assert.Equal(t, []sudoku.Coordinate{{Row: 1, Col: 3}, {Row: 1, Col: 4}}, hint.Cells)
+	assert.Equal(t, "R1C3 can't be 7. The pencil marks of R1C4 miss a value it can have.", hint.Explanation)
+}
+
+func TestNextHintUsesPencilMarks(t *testing.T) {
+	sudok := parseLine(t, classicSudoku)
+	// with the values of the solution as the only pencil marks every empty
+	// cell is a naked single, but full houses and hidden singles come first
+	progress := logic.Progress{PencilMarks: make(map[sudoku.Coordinate][]int)}
+	for i := 0; i < 81; i++ {
+		if classicSudoku[i] == '.' {
+			progress.PencilMarks[coordinate(i)] = []int{int(classicSolution[i] - '0')}
+		}
+	}
+	hint, ok, err := logic.NextHint(context.Background(), sudok, progress)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Empty(t, hint.Mistakes)
+	assert.Equal(t, logic.TechniqueHiddenSingle, hint.Step.Technique)
+	require.Len(t, hint.Step.Placements, 1)
+}
+
+func TestNextHintRejectsBrokenProgress(t *testing.T) {
+	// without the 1 at R9C8 the sudoku has many solutions, so the progress
+	// isn't checked for mistakes first
+	sudok := parseLine(t, "24.....86..3......1....25..59..1...2..7...3..8...4..97..58....3......6..32......9")
+	// the 4 at R1C3 repeats the given at R1C2
+	progress := logic.Progress{Values: map[sudoku.Coordinate]int{{Row: 1, Col: 3}: 4}}
+	_, _, err := logic.NextHint(context.Background(), sudok, progress)
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+func findFullHouse(g *Grid) (Step, bool, error) {
+	for _, house := range g.houses {
+		empty := -1
+		for _, i := range house {
+			if g.placed[i] {
+				continue
+			}
+			if empty >= 0 {
+				empty = -1
+				break
+			}
+			empty = i
+		}
+		if empty < 0 || g.candidates[empty].Len() != 1 {
+			continue
+		}
+		value := g.candidates[empty].Values()[0]
+		return Step{
+			Technique:  TechniqueFullHouse,
+			Values:     []int{value},
+			Cells:      g.coordinates(house),
+			Placements: []Candidate{{Coordinate: g.sudok.Coordinates[empty], Value: value}},
+		}, true, nil
+	}
+	return Step{}, false, nil
+}
+
+func findHiddenSingle(g *Grid) (Step, bool, error) {
+	for _, house := range g.houses {
+		for _, value := range g.sudok.PossibleValues {
+			if g.isPlacedIn(house, value) {
+				continue
+			}
+			cells := g.cellsWith(house, value)
+			if len(cells) != 1 {
+				continue
+			}
+			return Step{
+				Technique:  TechniqueHiddenSingle,
+				Values:     []int{value},
+				Cells:      g.coordinates(house),
+				Placements: []Candidate{{Coordinate: g.sudok.Coordinates[cells[0]], Value: value}},
+			}, true, nil
+		}
+	}
+	return Step{}, false, nil
+}
+
+func findNakedSingle(g *Grid) (Step, bool, error) {
+	for i, coord := range g.sudok.Coordinates {
+		if g.placed[i] || g.candidates[i].Len() != 1 {
+			continue
+		}
+		value := g.candidates[i].Values()[0]
+		return Step{
+			Technique:  TechniqueNakedSingle,
+			Values:     []int{value},
+			Cells:      []sudoku.Coordinate{coord},
+			Placements: []Candidate{{Coordinate: coord, Value: value}},
+		}, true, nil
+	}
+	return Step{}, false, nil
+}
+
+// findConstraintElimination lets every variant constraint that is a
+// sudoku.Propagator remove the candidates it rules out on its own.
+func findConstraintElimination(g *Grid) (Step, bool, error) {
+	for _, constr := range g.others {
+		propagator, ok := constr.(sudoku.Propagator)
+		if !ok {
+			continue
+		}
+		narrowed := g.Copy()
+		if err := propagator.Propagate(narrowed); err != nil {
+			return Step{}, false, fmt.Errorf("propagate %T: %w", constr, err)
+		}
+		coordinates := constr.ConstrainedCoordinates()
+		var eliminations []Candidate
+		for _, coord := range coordinates {
+			i, ok := g.index[coord]
+			if !ok || g.placed[i] {
+				continue
+			}
+			for _, value := range (g.candidates[i] &^ narrowed.candidates[i]).Values() {
+				eliminations = append(eliminations, Candidate{Coordinate: coord, Value: value})
+			}
+		}
+		if len(eliminations) == 0 {
+			continue
+		}
+		return Step{
+			Technique:    TechniqueConstraint,
+			Cells:        coordinates,
+			Eliminations: eliminations,
+		}, true, nil
+	}
+	return Step{}, false, nil
+}
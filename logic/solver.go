@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"fmt"
+	"sudoku-solver/sudoku"
+)
+
+type technique struct {
+	technique Technique
+	find      func(g *Grid) (Step, bool, error)
+}
+
+// techniques holds all techniques from the simplest to the hardest.
+var techniques = []technique{
+	{TechniqueFullHouse, findFullHouse},
+	{TechniqueHiddenSingle, findHiddenSingle},
+	{TechniqueNakedSingle, findNakedSingle},
+	{TechniqueConstraint, findConstraintElimination},
+	{TechniqueLockedCandidates, findLockedCandidates},
+	{TechniqueNakedPair, findNakedSubset(TechniqueNakedPair, 2)},
+	{TechniqueXWing, findFish(TechniqueXWing, 2)},
+	{TechniqueHiddenPair, findHiddenSubset(TechniqueHiddenPair, 2)},
+	{TechniqueNakedTriple, findNakedSubset(TechniqueNakedTriple, 3)},
+	{TechniqueSwordfish, findFish(TechniqueSwordfish, 3)},
+	{TechniqueHiddenTriple, findHiddenSubset(TechniqueHiddenTriple, 3)},
+	{TechniqueXYWing, findXYWing},
+	{TechniqueNakedQuad, findNakedSubset(TechniqueNakedQuad, 4)},
+	{TechniqueJellyfish, findFish(TechniqueJellyfish, 4)},
+	{TechniqueHiddenQuad, findHiddenSubset(TechniqueHiddenQuad, 4)},
+	{TechniqueSimpleColoring, findSimpleColoring},
+	{TechniqueChain, findChain},
+}
+
+// Techniques returns all techniques the solver knows from the simplest to the
+// hardest. NextStep tries them in this order.
+func Techniques() []Technique {
+	names := make([]Technique, 0, len(techniques))
+	for _, t := range techniques {
+		names = append(names, t.technique)
+	}
+	return names
+}
+
+// NextStep returns the step of the simplest technique that places or removes
+// a value in the grid. It returns false if no technique applies and an error
+// if the grid contradicts the constraints of the sudoku.
+func NextStep(g *Grid) (Step, bool, error) {
+	for _, t := range techniques {
+		step, ok, err := t.find(g)
+		if err != nil {
+			return Step{}, false, fmt.Errorf("%s: %w", t.technique, err)
+		}
+		if ok {
+			return step, true, nil
+		}
+	}
+	return Step{}, false, nil
+}
+
+// Solve applies the simplest step to the grid until it is solved or no
+// technique applies anymore. It never guesses. It returns the steps that were
+// applied, the grid is solved if the sudoku can be solved with the known
+// techniques.
+func Solve(g *Grid) ([]Step, error) {
+	steps := make([]Step, 0)
+	for !g.IsSolved() {
+		step, ok, err := NextStep(g)
+		if err != nil {
+			return steps, err
+		}
+		if !ok {
+			break
+		}
+		if err := g.Apply(step); err != nil {
+			return steps, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// SolveSudoku solves the sudoku from its givens, see Solve.
+func SolveSudoku(sudok sudoku.Sudoku) ([]Step, *Grid, error) {
+	g, err := NewGrid(sudok)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create grid: %w", err)
+	}
+	steps, err := Solve(g)
+	return steps, g, err
+}
@@ -0,0 +1,110 @@
+package logic_test
+
+import (
+	"context"
+	"strings"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/logic"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// parseLine parses a sudoku written in a single line with dots for empty
+// cells.
+func parseLine(t *testing.T, line string) sudoku.Sudoku {
+	rows := make([]string, 0, 9)
+	for i := 0; i < 81; i += 9 {
+		rows = append(rows, strings.ReplaceAll(line[i:i+9], ".", "-"))
+	}
+	sudok, err := sudokuio.ParseString(strings.Join(rows, "\n"))
+	require.NoError(t, err)
+	return *sudok
+}
+
+func TestSolveClassicSudoku(t *testing.T) {
+	sudok := parseLine(t, "24.....86..3......1....25..59..1...2..7...3..8...4..97..58....3......6..32.....19")
+	steps, g, err := logic.SolveSudoku(sudok)
+	require.NoError(t, err)
+	require.True(t, g.IsSolved())
+	require.NoError(t, sudok.Check(g))
+	assert.Equal(t, logic.TechniqueHiddenSingle, steps[0].Technique)
+}
+
+func TestStepsAreSound(t *testing.T) {
+	puzzles := []string{
+		"4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......",
+		"52...6.........7.13...........4..8..6......5...........418.........3..2...87.....",
+		"8..........36......7..9.2...5...7.......457.....1...3...1....68..85...1..9....4..",
+	}
+	for _, puzzle := range puzzles {
+		t.Run(puzzle, func(t *testing.T) {
+			sudok := parseLine(t, puzzle)
+			solution, err := backtrack.FindSolution(context.Background(), backtrack.ModePencilMarkMRV, sudok)
+			require.NoError(t, err)
+
+			g, err := logic.NewGrid(sudok)
+			require.NoError(t, err)
+			for !g.IsSolved() {
+				step, ok, err := logic.NextStep(g)
+				require.NoError(t, err)
+				if !ok {
+					break
+				}
+				require.NotEmpty(t, append(step.Placements, step.Eliminations...), step.String())
+				for _, placement := range step.Placements {
+					value, _ := solution.Get(placement.Coordinate)
+					require.Equal(t, value, placement.Value, step.String())
+				}
+				for _, elimination := range step.Eliminations {
+					require.True(t, g.CanBe(elimination.Coordinate, elimination.Value), step.String())
+					value, _ := solution.Get(elimination.Coordinate)
+					require.NotEqual(t, value, elimination.Value, step.String())
+				}
+				require.NoError(t, g.Apply(step))
+			}
+		})
+	}
+}
+
+func TestStepsAgreeWithSolutionGrid(t *testing.T) {
+	// every solution of a puzzle made of some values of a solved grid has
+	// to agree with all steps, so no step may remove a value of the grid
+	grid := "249135786753468921186972534594713862617289345832546197465891273971324658328657419"
+	rapid.Check(t, func(t *rapid.T) {
+		givens := rapid.SliceOfNDistinct(rapid.IntRange(0, 80), 22, 30, rapid.ID[int]).Draw(t, "givens")
+		line := []byte(strings.Repeat(".", 81))
+		for _, i := range givens {
+			line[i] = grid[i]
+		}
+		rows := make([]string, 0, 9)
+		for i := 0; i < 81; i += 9 {
+			rows = append(rows, strings.ReplaceAll(string(line[i:i+9]), ".", "-"))
+		}
+		sudok, err := sudokuio.ParseString(strings.Join(rows, "\n"))
+		require.NoError(t, err)
+
+		g, err := logic.NewGrid(*sudok)
+		require.NoError(t, err)
+		for !g.IsSolved() {
+			step, ok, err := logic.NextStep(g)
+			require.NoError(t, err)
+			if !ok {
+				break
+			}
+			for _, placement := range step.Placements {
+				i := (placement.Coordinate.Row-1)*9 + placement.Coordinate.Col - 1
+				require.Equal(t, int(grid[i]-'0'), placement.Value, step.String())
+			}
+			for _, elimination := range step.Eliminations {
+				i := (elimination.Coordinate.Row-1)*9 + elimination.Coordinate.Col - 1
+				require.NotEqual(t, int(grid[i]-'0'), elimination.Value, step.String())
+			}
+			require.NoError(t, g.Apply(step))
+		}
+	})
+}
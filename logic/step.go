@@ -0,0 +1,150 @@
+package logic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sudoku-solver/sudoku"
+)
+
+// Technique is the name of a way to deduce values by hand.
+type Technique string
+
+const (
+	// TechniqueFullHouse places the last missing value of a house.
+	TechniqueFullHouse Technique = "full house"
+	// TechniqueHiddenSingle places a value that only one cell of a house can
+	// have.
+	TechniqueHiddenSingle Technique = "hidden single"
+	// TechniqueNakedSingle places the only candidate of a cell.
+	TechniqueNakedSingle Technique = "naked single"
+	// TechniqueConstraint removes the candidates that a variant constraint
+	// rules out on its own, e.g. the sums a killer cage can't reach.
+	TechniqueConstraint Technique = "constraint"
+	// TechniqueLockedCandidates removes a value from a house if the value of
+	// another house can only be in the cells both houses share.
+	TechniqueLockedCandidates Technique = "locked candidates"
+	// TechniqueNakedPair, TechniqueNakedTriple and TechniqueNakedQuad remove
+	// values from a group if two (three, four) of its cells can only have
+	// the same two (three, four) values.
+	TechniqueNakedPair   Technique = "naked pair"
+	TechniqueNakedTriple Technique = "naked triple"
+	TechniqueNakedQuad   Technique = "naked quad"
+	// TechniqueHiddenPair, TechniqueHiddenTriple and TechniqueHiddenQuad
+	// remove the other candidates of two (three, four) cells of a house that
+	// are the only cells that can have two (three, four) values.
+	TechniqueHiddenPair   Technique = "hidden pair"
+	TechniqueHiddenTriple Technique = "hidden triple"
+	TechniqueHiddenQuad   Technique = "hidden quad"
+	// TechniqueXWing, TechniqueSwordfish and TechniqueJellyfish remove a value
+	// from two (three, four) columns if the value can only be in those
+	// columns in two (three, four) rows, or the other way around.
+	TechniqueXWing     Technique = "x-wing"
+	TechniqueSwordfish Technique = "swordfish"
+	TechniqueJellyfish Technique = "jellyfish"
+	// TechniqueXYWing removes a value from the cells that see two cells with
+	// the candidates XZ and YZ, which both see a cell with the candidates XY.
+	TechniqueXYWing Technique = "xy-wing"
+	// TechniqueSimpleColoring colors the cells of a value that are linked by
+	// houses with only two places for the value in alternating colors. One
+	// color is true, so a color that sees itself is false and cells that see
+	// both colors can't have the value.
+	TechniqueSimpleColoring Technique = "simple coloring"
+	// TechniqueChain follows an alternating inference chain: if one end of the
+	// chain is false, the other one is true, so candidates that see both ends
+	// are false.
+	TechniqueChain Technique = "chain"
+)
+
+// Candidate is a value of a cell.
+type Candidate struct {
+	Coordinate sudoku.Coordinate
+	Value      int
+}
+
+// Step is a single deduction: the technique that was used, the values and
+// cells it is based on and the values it places or removes.
+type Step struct {
+	Technique Technique
+	// Values are the values the deduction is about, e.g. the two values of a
+	// naked pair. Chains list the value of every cell of the chain.
+	Values []int
+	// Cells are the cells the deduction is based on, e.g. the two cells of a
+	// naked pair. Chains list their cells in the order of the chain.
+	Cells []sudoku.Coordinate
+	// Placements are the values the step places.
+	Placements []Candidate
+	// Eliminations are the candidates the step removes.
+	Eliminations []Candidate
+}
+
+// cellName returns the name of a coordinate in the form R1C2.
+func cellName(coordinate sudoku.Coordinate) string {
+	return fmt.Sprintf("R%dC%d", coordinate.Row, coordinate.Col)
+}
+
+func cellNames(coordinates []sudoku.Coordinate) string {
+	names := make([]string, 0, len(coordinates))
+	for _, coord := range coordinates {
+		names = append(names, cellName(coord))
+	}
+	return strings.Join(names, " ")
+}
+
+// String describes the step, e.g. "naked pair 4 7 in R1C2 R1C5: remove 4
+// from R1C3, 7 from R1C3 R1C9".
+func (s Step) String() string {
+	var sb strings.Builder
+	sb.WriteString(string(s.Technique))
+	if s.Technique == TechniqueChain && len(s.Values) == len(s.Cells) {
+		// the links of a chain alternate between strong (=) and weak (-)
+		sb.WriteString(" ")
+		for i, coord := range s.Cells {
+			if i > 0 {
+				sb.WriteString([]string{"-", "="}[i%2])
+			}
+			fmt.Fprintf(&sb, "%d%s", s.Values[i], cellName(coord))
+		}
+	} else {
+		for _, value := range s.Values {
+			sb.WriteString(" ")
+			sb.WriteString(strconv.Itoa(value))
+		}
+		if len(s.Cells) > 0 {
+			sb.WriteString(" in ")
+			sb.WriteString(cellNames(s.Cells))
+		}
+	}
+	sb.WriteString(":")
+	for i, placement := range s.Placements {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, " place %d at %s", placement.Value, cellName(placement.Coordinate))
+	}
+	if len(s.Eliminations) == 0 {
+		return sb.String()
+	}
+	if len(s.Placements) > 0 {
+		sb.WriteString(",")
+	}
+	sb.WriteString(" remove")
+	// group the eliminations by value
+	cells := make(map[int][]sudoku.Coordinate)
+	values := make([]int, 0)
+	for _, elimination := range s.Eliminations {
+		if _, ok := cells[elimination.Value]; !ok {
+			values = append(values, elimination.Value)
+		}
+		cells[elimination.Value] = append(cells[elimination.Value], elimination.Coordinate)
+	}
+	sort.Ints(values)
+	for i, value := range values {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, " %d from %s", value, cellNames(cells[value]))
+	}
+	return sb.String()
+}
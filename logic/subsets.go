@@ -0,0 +1,175 @@
+package logic
+
+import "slices"
+
+// combinations calls f with every combination of n items in the order of the
+// items until f returns true. It returns whether f returned true.
+func combinations(items []int, n int, f func([]int) bool) bool {
+	chosen := make([]int, 0, n)
+	var choose func(start int) bool
+	choose = func(start int) bool {
+		if len(chosen) == n {
+			return f(chosen)
+		}
+		for i := start; i <= len(items)-(n-len(chosen)); i++ {
+			chosen = append(chosen, items[i])
+			if choose(i + 1) {
+				return true
+			}
+			chosen = chosen[:len(chosen)-1]
+		}
+		return false
+	}
+	return choose(0)
+}
+
+// findLockedCandidates looks for a value of a house that can only be in the
+// cells the house shares with another group. This covers pointing (a box
+// locks a value into a line) and claiming (a line locks a value into a box).
+func findLockedCandidates(g *Grid) (Step, bool, error) {
+	for _, house := range g.houses {
+		for _, group := range g.groups {
+			shared := make([]int, 0, len(house))
+			for _, i := range house {
+				if slices.Contains(group, i) {
+					shared = append(shared, i)
+				}
+			}
+			if len(shared) < 2 || len(shared) == len(group) {
+				continue
+			}
+			for _, value := range g.sudok.PossibleValues {
+				if g.isPlacedIn(house, value) {
+					continue
+				}
+				cells := g.cellsWith(house, value)
+				if len(cells) < 2 || !isSubset(cells, shared) {
+					continue
+				}
+				rest := make([]int, 0, len(group))
+				for _, i := range group {
+					if !slices.Contains(shared, i) {
+						rest = append(rest, i)
+					}
+				}
+				eliminations := g.eliminations(rest, newValueSet(value))
+				if len(eliminations) == 0 {
+					continue
+				}
+				return Step{
+					Technique:    TechniqueLockedCandidates,
+					Values:       []int{value},
+					Cells:        g.coordinates(cells),
+					Eliminations: eliminations,
+				}, true, nil
+			}
+		}
+	}
+	return Step{}, false, nil
+}
+
+func isSubset(cells, of []int) bool {
+	for _, i := range cells {
+		if !slices.Contains(of, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// findNakedSubset returns a finder for n cells of a group that only have n
+// candidates together, which can't be anywhere else in the group.
+func findNakedSubset(technique Technique, n int) func(g *Grid) (Step, bool, error) {
+	return func(g *Grid) (Step, bool, error) {
+		var step Step
+		found := false
+		for _, group := range g.groups {
+			empty := make([]int, 0, len(group))
+			for _, i := range group {
+				if !g.placed[i] && g.candidates[i].Len() <= n {
+					empty = append(empty, i)
+				}
+			}
+			found = combinations(empty, n, func(cells []int) bool {
+				var values valueSet
+				for _, i := range cells {
+					values |= g.candidates[i]
+				}
+				if values.Len() != n {
+					return false
+				}
+				rest := make([]int, 0, len(group))
+				for _, i := range group {
+					if !slices.Contains(cells, i) {
+						rest = append(rest, i)
+					}
+				}
+				eliminations := g.eliminations(rest, values)
+				if len(eliminations) == 0 {
+					return false
+				}
+				step = Step{
+					Technique:    technique,
+					Values:       values.Values(),
+					Cells:        g.coordinates(cells),
+					Eliminations: eliminations,
+				}
+				return true
+			})
+			if found {
+				return step, true, nil
+			}
+		}
+		return Step{}, false, nil
+	}
+}
+
+// findHiddenSubset returns a finder for n values of a house that can only be
+// in the same n cells, which therefore can't have any other value.
+func findHiddenSubset(technique Technique, n int) func(g *Grid) (Step, bool, error) {
+	return func(g *Grid) (Step, bool, error) {
+		var step Step
+		found := false
+		for _, house := range g.houses {
+			missing := make([]int, 0, len(g.sudok.PossibleValues))
+			for _, value := range g.sudok.PossibleValues {
+				if !g.isPlacedIn(house, value) {
+					missing = append(missing, value)
+				}
+			}
+			found = combinations(missing, n, func(values []int) bool {
+				cells := make([]int, 0, n)
+				for _, i := range house {
+					if g.placed[i] {
+						continue
+					}
+					for _, value := range values {
+						if g.candidates[i].Contains(value) {
+							cells = append(cells, i)
+							break
+						}
+					}
+				}
+				if len(cells) != n {
+					return false
+				}
+				others := newValueSet(g.sudok.PossibleValues...) &^ newValueSet(values...)
+				eliminations := g.eliminations(cells, others)
+				if len(eliminations) == 0 {
+					return false
+				}
+				step = Step{
+					Technique:    technique,
+					Values:       slices.Clone(values),
+					Cells:        g.coordinates(cells),
+					Eliminations: eliminations,
+				}
+				return true
+			})
+			if found {
+				return step, true, nil
+			}
+		}
+		return Step{}, false, nil
+	}
+}
@@ -0,0 +1,165 @@
+package logic
+
+import (
+	"strings"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func emptyClassicGrid(t *testing.T) *Grid {
+	sudok, err := sudokuio.ParseString(strings.TrimSpace(strings.Repeat("--- --- ---\n", 9)))
+	require.NoError(t, err)
+	g, err := NewGrid(*sudok)
+	require.NoError(t, err)
+	return g
+}
+
+// keepOnlyIn removes the value from all cells of a line except the listed
+// ones.
+func keepOnlyIn(t *testing.T, g *Grid, value int, inLine func(sudoku.Coordinate) bool, keep ...sudoku.Coordinate) {
+	for _, coord := range g.sudok.Coordinates {
+		if !inLine(coord) {
+			continue
+		}
+		kept := false
+		for _, k := range keep {
+			kept = kept || k == coord
+		}
+		if !kept {
+			require.NoError(t, g.Eliminate(coord, value))
+		}
+	}
+}
+
+func row(r int) func(sudoku.Coordinate) bool {
+	return func(c sudoku.Coordinate) bool { return c.Row == r }
+}
+
+func col(c int) func(sudoku.Coordinate) bool {
+	return func(coord sudoku.Coordinate) bool { return coord.Col == c }
+}
+
+func eliminated(step Step, value int) []sudoku.Coordinate {
+	coordinates := make([]sudoku.Coordinate, 0)
+	for _, elimination := range step.Eliminations {
+		if elimination.Value == value {
+			coordinates = append(coordinates, elimination.Coordinate)
+		}
+	}
+	return coordinates
+}
+
+func TestFindXWing(t *testing.T) {
+	g := emptyClassicGrid(t)
+	keepOnlyIn(t, g, 5, row(1), sudoku.Coordinate{Row: 1, Col: 2}, sudoku.Coordinate{Row: 1, Col: 7})
+	keepOnlyIn(t, g, 5, row(5), sudoku.Coordinate{Row: 5, Col: 2}, sudoku.Coordinate{Row: 5, Col: 7})
+
+	step, ok, err := findFish(TechniqueXWing, 2)(g)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, TechniqueXWing, step.Technique)
+	assert.Equal(t, []int{5}, step.Values)
+	// 5 is removed from both columns except in rows 1 and 5
+	removed := eliminated(step, 5)
+	assert.Len(t, removed, 14)
+	for _, coord := range removed {
+		assert.Contains(t, []int{2, 7}, coord.Col)
+		assert.NotContains(t, []int{1, 5}, coord.Row)
+	}
+}
+
+func TestFindXYWing(t *testing.T) {
+	g := emptyClassicGrid(t)
+	require.NoError(t, g.SetCandidates(sudoku.Coordinate{Row: 1, Col: 1}, []int{1, 2}))
+	require.NoError(t, g.SetCandidates(sudoku.Coordinate{Row: 1, Col: 5}, []int{1, 3}))
+	require.NoError(t, g.SetCandidates(sudoku.Coordinate{Row: 2, Col: 2}, []int{2, 3}))
+	expected := []sudoku.Coordinate{
+		{Row: 1, Col: 2}, {Row: 1, Col: 3},
+		{Row: 2, Col: 4}, {Row: 2, Col: 5}, {Row: 2, Col: 6},
+	}
+
+	step, ok, err := findXYWing(g)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 5}, {Row: 2, Col: 2}}, step.Cells)
+	assert.ElementsMatch(t, expected, eliminated(step, 3))
+	assert.Len(t, step.Eliminations, len(expected))
+
+	// an xy-wing is a short chain
+	step, ok, err = findChain(g)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, step.Cells, 6)
+	assert.ElementsMatch(t, expected, eliminated(step, 3))
+	assert.Len(t, step.Eliminations, len(expected))
+}
+
+func TestFindSimpleColoring(t *testing.T) {
+	// 7 is linked R1C1 = R1C5 = R5C5 = R5C2, so R2C2 sees both colors
+	g := emptyClassicGrid(t)
+	keepOnlyIn(t, g, 7, row(1), sudoku.Coordinate{Row: 1, Col: 1}, sudoku.Coordinate{Row: 1, Col: 5})
+	keepOnlyIn(t, g, 7, col(5), sudoku.Coordinate{Row: 1, Col: 5}, sudoku.Coordinate{Row: 5, Col: 5})
+	keepOnlyIn(t, g, 7, row(5), sudoku.Coordinate{Row: 5, Col: 5}, sudoku.Coordinate{Row: 5, Col: 2})
+
+	step, ok, err := findSimpleColoring(g)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []int{7}, step.Values)
+	assert.Contains(t, eliminated(step, 7), sudoku.Coordinate{Row: 2, Col: 2})
+	for _, elimination := range step.Eliminations {
+		assert.Equal(t, 7, elimination.Value)
+	}
+}
+
+func TestFindHiddenPair(t *testing.T) {
+	g := emptyClassicGrid(t)
+	keepOnlyIn(t, g, 4, row(9), sudoku.Coordinate{Row: 9, Col: 3}, sudoku.Coordinate{Row: 9, Col: 8})
+	keepOnlyIn(t, g, 6, row(9), sudoku.Coordinate{Row: 9, Col: 3}, sudoku.Coordinate{Row: 9, Col: 8})
+
+	step, ok, err := findHiddenSubset(TechniqueHiddenPair, 2)(g)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []int{4, 6}, step.Values)
+	assert.Equal(t, []sudoku.Coordinate{{Row: 9, Col: 3}, {Row: 9, Col: 8}}, step.Cells)
+	assert.Len(t, step.Eliminations, 14)
+	require.NoError(t, g.Apply(step))
+	assert.Equal(t, []int{4, 6}, g.Candidates(sudoku.Coordinate{Row: 9, Col: 3}))
+}
+
+func TestFindConstraintElimination(t *testing.T) {
+	sudok, err := sudokuio.ParseString(strings.TrimSpace(strings.Repeat("--- --- ---\n", 9)))
+	require.NoError(t, err)
+	arrow, err := constraint.NewArrowConstraint(sudoku.Coordinate{Row: 1, Col: 1},
+		[]sudoku.Coordinate{{Row: 1, Col: 2}, {Row: 1, Col: 3}})
+	require.NoError(t, err)
+	sudok.Constraints = append(sudok.Constraints, arrow)
+	g, err := NewGrid(*sudok)
+	require.NoError(t, err)
+
+	step, ok, err := NextStep(g)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, TechniqueConstraint, step.Technique)
+	// the two values on the arrow sum up to at least 2 and at most 8
+	assert.Contains(t, step.Eliminations, Candidate{Coordinate: sudoku.Coordinate{Row: 1, Col: 1}, Value: 1})
+	assert.Contains(t, step.Eliminations, Candidate{Coordinate: sudoku.Coordinate{Row: 1, Col: 2}, Value: 9})
+}
+
+func TestStepString(t *testing.T) {
+	step := Step{
+		Technique: TechniqueNakedPair,
+		Values:    []int{4, 7},
+		Cells:     []sudoku.Coordinate{{Row: 1, Col: 2}, {Row: 1, Col: 5}},
+		Eliminations: []Candidate{
+			{Coordinate: sudoku.Coordinate{Row: 1, Col: 3}, Value: 4},
+			{Coordinate: sudoku.Coordinate{Row: 1, Col: 3}, Value: 7},
+			{Coordinate: sudoku.Coordinate{Row: 1, Col: 9}, Value: 7},
+		},
+	}
+	assert.Equal(t, "naked pair 4 7 in R1C2 R1C5: remove 4 from R1C3, 7 from R1C3 R1C9", step.String())
+}
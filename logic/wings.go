@@ -0,0 +1,46 @@
+package logic
+
+// findXYWing looks for a pivot with the candidates XY that sees a cell with
+// the candidates XZ and a cell with the candidates YZ. Whichever value the
+// pivot has, one of the two cells is Z, so cells that see both can't be Z.
+func findXYWing(g *Grid) (Step, bool, error) {
+	for pivot := range g.sudok.Coordinates {
+		if g.placed[pivot] || g.candidates[pivot].Len() != 2 {
+			continue
+		}
+		for _, pincer1 := range g.peers[pivot] {
+			if g.placed[pincer1] || g.candidates[pincer1].Len() != 2 {
+				continue
+			}
+			shared := g.candidates[pivot] & g.candidates[pincer1]
+			if shared.Len() != 1 {
+				continue
+			}
+			z := g.candidates[pincer1] &^ shared
+			// the second pincer has the other value of the pivot and z
+			wanted := g.candidates[pivot]&^shared | z
+			for _, pincer2 := range g.peers[pivot] {
+				if pincer2 == pincer1 || g.placed[pincer2] || g.candidates[pincer2] != wanted {
+					continue
+				}
+				seeingBoth := make([]int, 0)
+				for _, i := range g.peers[pincer1] {
+					if i != pivot && i != pincer2 && g.sees[pincer2][i] {
+						seeingBoth = append(seeingBoth, i)
+					}
+				}
+				eliminations := g.eliminations(seeingBoth, z)
+				if len(eliminations) == 0 {
+					continue
+				}
+				return Step{
+					Technique:    TechniqueXYWing,
+					Values:       (g.candidates[pivot] | z).Values(),
+					Cells:        g.coordinates([]int{pivot, pincer1, pincer2}),
+					Eliminations: eliminations,
+				}, true, nil
+			}
+		}
+	}
+	return Step{}, false, nil
+}
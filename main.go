@@ -2,14 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"math"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sudoku-solver/backtrack"
+	"sudoku-solver/generate"
+	"sudoku-solver/logic"
+	"sudoku-solver/rating"
+	"sudoku-solver/sat"
 	"sudoku-solver/sudoku"
 	"sudoku-solver/sudokuio"
 	"time"
@@ -23,7 +31,27 @@ func main() {
 }
 
 func run() error {
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		return runConvert(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rate" {
+		return runRate(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		return runGenerate(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reduce" {
+		return runReduce(os.Args[2:])
+	}
+
 	modeString := flag.String("mode", "pencilmark", "mode to use for solving")
+	workers := flag.Int("workers", 1, "number of workers searching in parallel, 0 uses all cores")
+	nogoods := flag.Int("nogoods", backtrack.DefaultNogoodLimit, "maximum number of nogoods the nogood mode keeps, 0 keeps all")
+	count := flag.Bool("count", false, "only count the solutions instead of printing them")
+	limit := flag.Int("limit", 0, "stop counting after this many solutions, 0 counts all")
+	steps := flag.Bool("steps", false, "solve with human techniques and print every step")
+	backbone := flag.Bool("backbone", false, "print the values every cell has in at least one solution")
+	unique := flag.Bool("unique", false, "check whether the solution is unique and print the ambiguous cells otherwise")
 	flag.Parse()
 
 	args := flag.Args()
@@ -31,43 +59,47 @@ func run() error {
 	if len(args) < 1 {
 		return fmt.Errorf("no input file specified")
 	}
-	inputFilePath := args[0]
-
-	mode, err := backtrack.ParseMode(*modeString)
+	sudok, err := readSudoku(args[0])
 	if err != nil {
-		return fmt.Errorf("parse mode: %w", err)
+		return err
 	}
 
-	// read the input file
-	inputBytes, err := os.ReadFile(inputFilePath)
+	mode, err := backtrack.ParseMode(*modeString, *sudok)
 	if err != nil {
-		return fmt.Errorf("read input file: %w", err)
+		return fmt.Errorf("parse mode: %w", err)
 	}
-
-	var sudok *sudoku.Sudoku
-	if strings.HasSuffix(inputFilePath, ".txt") {
-		// parse the input file into a string
-		sudok, err = sudokuio.ParseString(string(inputBytes))
-		if err != nil {
-			return fmt.Errorf("parse txt file: %w", err)
-		}
-	} else if strings.HasSuffix(inputFilePath, ".json") {
-		// parse the input file as JSON
-		sudok, err = sudokuio.ParseJSON(inputBytes)
-		if err != nil {
-			return fmt.Errorf("parse json file: %w", err)
-		}
-	} else {
-		return fmt.Errorf("unknown input file type")
+	if mode == backtrack.ModeNogood {
+		mode = backtrack.NogoodMode(*nogoods)
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if *count {
+		return runCount(ctx, mode, *sudok, *limit)
+	}
+	if *unique {
+		return runUnique(ctx, mode, *sudok)
+	}
+	if *backbone {
+		return runBackbone(ctx, mode, *sudok)
+	}
+	if *steps {
+		return runSteps(*sudok)
+	}
+
 	// solve the sudoku
 	slog.Info("starting to solve", slog.String("mode", mode.String()))
 	start := time.Now()
-	solutions := backtrack.FindSolutions(ctx, mode, *sudok)
+	var solutions <-chan sudoku.Solution
+	if *workers == 1 {
+		solutions, err = backtrack.FindSolutions(ctx, mode, *sudok)
+	} else {
+		solutions, err = backtrack.FindSolutionsParallel(ctx, mode, *sudok, *workers)
+	}
+	if err != nil {
+		return fmt.Errorf("find solutions: %w", err)
+	}
 	ticker := time.NewTicker(30 * time.Second)
 	solutionCount := 0
 
@@ -98,19 +130,403 @@ func run() error {
 				slog.Duration("searchDuration", time.Since(start)),
 			)
 
-			// print the solution
-			lastRow := math.MinInt
-			for _, coord := range sudok.Coordinates {
-				value, ok := solution.Get(coord)
-				if !ok {
-					return fmt.Errorf("solution missing value for coordinate %v", coord)
-				}
-				if lastRow != coord.Row {
-					fmt.Println()
-					lastRow = coord.Row
-				}
-				fmt.Printf("%d", value)
+			if err := printSolution(*sudok, solution); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// runCount counts the solutions of the sudoku and prints the count.
+func runCount(ctx context.Context, mode backtrack.Mode, sudok sudoku.Sudoku, limit int) error {
+	slog.Info("starting to count", slog.String("mode", mode.String()), slog.Int("limit", limit))
+	start := time.Now()
+	count, limitHit, err := backtrack.CountSolutions(ctx, mode, sudok, limit)
+	if err != nil {
+		if !errors.Is(err, ctx.Err()) {
+			return fmt.Errorf("count solutions: %w", err)
+		}
+		slog.Info("stopped counting",
+			slog.Int("foundSolutions", count),
+			slog.Duration("searchDuration", time.Since(start)),
+		)
+		return nil
+	}
+	slog.Info("finished counting",
+		slog.Int("foundSolutions", count),
+		slog.Bool("limitHit", limitHit),
+		slog.Duration("searchDuration", time.Since(start)),
+	)
+	if limitHit {
+		fmt.Printf("at least %d solutions\n", count)
+	} else {
+		fmt.Printf("%d solutions\n", count)
+	}
+	return nil
+}
+
+// runUnique checks whether the sudoku has a unique solution. If it has
+// multiple, it prints two of them and the cells where they differ.
+func runUnique(ctx context.Context, mode backtrack.Mode, sudok sudoku.Sudoku) error {
+	uniqueness, err := backtrack.CheckUniqueness(ctx, mode, sudok)
+	if err != nil {
+		return fmt.Errorf("check uniqueness: %w", err)
+	}
+	switch {
+	case !uniqueness.HasSolution():
+		fmt.Println("no solution")
+		return nil
+	case uniqueness.IsUnique():
+		fmt.Print("unique solution")
+		if err := printSolution(sudok, uniqueness.Solutions[0]); err != nil {
+			return err
+		}
+		fmt.Println()
+		return nil
+	}
+	fmt.Print("multiple solutions")
+	for _, solution := range uniqueness.Solutions {
+		if err := printSolution(sudok, solution); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	cells := make([]string, 0, len(uniqueness.Differences))
+	for _, coord := range uniqueness.Differences {
+		cells = append(cells, fmt.Sprintf("R%dC%d", coord.Row, coord.Col))
+	}
+	fmt.Printf("\nthe solutions differ at %s\n", strings.Join(cells, " "))
+	return nil
+}
+
+// runBackbone prints the true candidates of every cell. Cells that have the
+// same value in all solutions are printed as that value, all others as the
+// list of their candidates in brackets.
+func runBackbone(ctx context.Context, mode backtrack.Mode, sudok sudoku.Sudoku) error {
+	backbone, err := backtrack.FindBackbone(ctx, mode, sudok)
+	if err != nil {
+		return fmt.Errorf("find backbone: %w", err)
+	}
+	lastRow := math.MinInt
+	for _, coord := range sudok.Coordinates {
+		if lastRow != coord.Row {
+			fmt.Println()
+			lastRow = coord.Row
+		}
+		if value, ok := backbone.Fixed(coord); ok {
+			fmt.Printf("%d", value)
+			continue
+		}
+		fmt.Print("[")
+		for _, value := range backbone.Candidates[coord] {
+			fmt.Printf("%d", value)
+		}
+		fmt.Print("]")
+	}
+	fmt.Println()
+	return nil
+}
+
+// runSteps solves the sudoku with human techniques and prints every step and
+// the grid that was reached.
+func runSteps(sudok sudoku.Sudoku) error {
+	steps, grid, err := logic.SolveSudoku(sudok)
+	for _, step := range steps {
+		fmt.Println(step)
+	}
+	if err != nil {
+		return fmt.Errorf("solve logically: %w", err)
+	}
+	if !grid.IsSolved() {
+		fmt.Println("no technique applies anymore")
+		return nil
+	}
+	if err := printSolution(sudok, grid); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
+// runConvert writes the sudoku in the input file in another format to stdout.
+func runConvert(arguments []string) error {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := flags.String("to", "dimacs", "format to convert to, only dimacs is supported")
+	if err := flags.Parse(arguments); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		return fmt.Errorf("no input file specified")
+	}
+	sudok, err := readSudoku(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	switch *to {
+	case "dimacs":
+		encoding, err := sat.Encode(*sudok)
+		if err != nil {
+			return fmt.Errorf("encode sudoku: %w", err)
+		}
+		if err := encoding.WriteDIMACS(os.Stdout); err != nil {
+			return fmt.Errorf("write dimacs: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %s", *to)
+	}
+}
+
+// runRate rates the sudokus in the input files and prints one line per file
+// with the score, the level, the hardest technique, the number of steps and
+// guesses and the path.
+func runRate(arguments []string) error {
+	flags := flag.NewFlagSet("rate", flag.ExitOnError)
+	sortByScore := flags.Bool("sort", false, "print the sudokus from the easiest to the hardest")
+	if err := flags.Parse(arguments); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		return fmt.Errorf("no input file specified")
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	type rated struct {
+		path   string
+		rating rating.Rating
+	}
+	ratings := make([]rated, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		sudok, err := readSudoku(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		r, err := rating.Rate(ctx, *sudok)
+		if err != nil {
+			return fmt.Errorf("rate %s: %w", path, err)
+		}
+		ratings = append(ratings, rated{path: path, rating: r})
+	}
+	if *sortByScore {
+		sort.SliceStable(ratings, func(i, j int) bool {
+			return ratings[i].rating.Score < ratings[j].rating.Score
+		})
+	}
+	for _, r := range ratings {
+		hardest := string(r.rating.Hardest)
+		if !r.rating.Solved {
+			hardest = "guessing"
+		}
+		fmt.Printf("%.1f\t%s\t%s\t%d\t%d\t%s\n", r.rating.Score, r.rating.Level(), hardest, r.rating.Steps, r.rating.Guesses, r.path)
+	}
+	return nil
+}
+
+// runGenerate generates sudokus with the normal rules and the chosen variant
+// rules and prints them or writes one file per sudoku named after its seed.
+func runGenerate(arguments []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the first sudoku, the following sudokus use the next seeds")
+	count := flags.Int("count", 1, "number of sudokus to generate")
+	clues := flags.Int("clues", 0, "number of clues, 0 removes as many as possible")
+	level := flags.String("level", "", "level of the sudokus: easy, medium, hard, expert or extreme")
+	format := flags.String("format", "text", "output format: text or json")
+	out := flags.String("out", "", "directory to write the sudokus to, empty prints them")
+	killer := flags.Bool("killer", false, "divide the grid into killer cages")
+	thermometers := flags.Int("thermometers", 0, "number of thermometers")
+	antiKnight := flags.Bool("antiknight", false, "forbid the same value a knight's move apart")
+	if err := flags.Parse(arguments); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+	rules := generate.Rules{
+		KillerCages:  *killer,
+		Thermometers: *thermometers,
+		AntiKnight:   *antiKnight,
+	}
+	if rules != (generate.Rules{}) && *format == "text" {
+		return fmt.Errorf("variant rules can only be written as json")
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	for i := 0; i < *count; i++ {
+		options := generate.Options{
+			Seed:  *seed + int64(i),
+			Clues: *clues,
+			Level: rating.Level(*level),
+		}
+		puzzle, err := generate.Variant(ctx, rules, options)
+		if err != nil {
+			return fmt.Errorf("generate sudoku with seed %d: %w", options.Seed, err)
+		}
+		slog.Info("generated sudoku",
+			slog.Int64("seed", options.Seed),
+			slog.Int("clues", len(puzzle.Clues)),
+			slog.Float64("score", puzzle.Rating.Score),
+			slog.String("level", string(puzzle.Rating.Level())),
+		)
+		output, extension := []byte(sudokuio.FormatString(puzzle.Sudoku)), "txt"
+		if *format == "json" {
+			extension = "json"
+			description, err := puzzle.JSON()
+			if err != nil {
+				return fmt.Errorf("describe sudoku: %w", err)
+			}
+			output, err = json.MarshalIndent(description, "", "\t")
+			if err != nil {
+				return fmt.Errorf("encode json: %w", err)
+			}
+			output = append(output, '\n')
+		}
+		if *out == "" {
+			if i > 0 {
+				fmt.Println()
+			}
+			if _, err := os.Stdout.Write(output); err != nil {
+				return fmt.Errorf("print sudoku: %w", err)
 			}
+			continue
+		}
+		path := filepath.Join(*out, fmt.Sprintf("%d.%s", options.Seed, extension))
+		if err := os.WriteFile(path, output, 0o644); err != nil {
+			return fmt.Errorf("write sudoku: %w", err)
+		}
+	}
+	return nil
+}
+
+// runReduce removes the clues of the sudoku in the input file that are not
+// needed for a unique solution and prints the removed clues and the clues that
+// are left.
+func runReduce(arguments []string) error {
+	flags := flag.NewFlagSet("reduce", flag.ExitOnError)
+	variants := flags.Bool("variants", false, "also remove variant constraints that are not needed")
+	if err := flags.Parse(arguments); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		return fmt.Errorf("no input file specified")
+	}
+	inputFilePath := flags.Arg(0)
+	description, err := readDescription(inputFilePath)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	minimal, err := generate.Minimize(ctx, description, *variants)
+	if err != nil {
+		return fmt.Errorf("minimize: %w", err)
+	}
+	for _, clue := range minimal.RemovedClues {
+		fmt.Printf("removed clue %d at R%dC%d\n", clue.Value, clue.Coordinate.Row, clue.Coordinate.Col)
+	}
+	for _, c := range minimal.RemovedConstraints {
+		removed, err := json.Marshal(c)
+		if err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+		fmt.Printf("removed constraint %s\n", removed)
+	}
+	if !strings.HasSuffix(inputFilePath, ".json") {
+		fmt.Print(sudokuio.FormatString(minimal.Sudoku))
+		return nil
+	}
+	output, err := json.MarshalIndent(minimal.JSON, "", "\t")
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+// readDescription reads a sudoku from a .txt or .json file without creating
+// its constraints. A text file becomes a normal field with the normal sudoku
+// rules.
+func readDescription(inputFilePath string) (sudokuio.JSONSudoku, error) {
+	inputBytes, err := os.ReadFile(inputFilePath)
+	if err != nil {
+		return sudokuio.JSONSudoku{}, fmt.Errorf("read input file: %w", err)
+	}
+	switch {
+	case strings.HasSuffix(inputFilePath, ".txt"):
+		sudok, err := sudokuio.ParseString(string(inputBytes))
+		if err != nil {
+			return sudokuio.JSONSudoku{}, fmt.Errorf("parse txt file: %w", err)
+		}
+		return sudokuio.ClassicJSON(*sudok), nil
+	case strings.HasSuffix(inputFilePath, ".json"):
+		description, err := sudokuio.ParseJSONSudoku(inputBytes)
+		if err != nil {
+			return sudokuio.JSONSudoku{}, fmt.Errorf("parse json file: %w", err)
+		}
+		return description, nil
+	default:
+		return sudokuio.JSONSudoku{}, fmt.Errorf("unknown input file type")
+	}
+}
+
+// readSudoku reads a sudoku from a .txt or .json file.
+func readSudoku(inputFilePath string) (*sudoku.Sudoku, error) {
+	inputBytes, err := os.ReadFile(inputFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("read input file: %w", err)
+	}
+
+	var sudok *sudoku.Sudoku
+	if strings.HasSuffix(inputFilePath, ".txt") {
+		// parse the input file into a string
+		sudok, err = sudokuio.ParseString(string(inputBytes))
+		if err != nil {
+			return nil, fmt.Errorf("parse txt file: %w", err)
+		}
+	} else if strings.HasSuffix(inputFilePath, ".json") {
+		// parse the input file as JSON
+		sudok, err = sudokuio.ParseJSON(inputBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse json file: %w", err)
+		}
+	} else {
+		return nil, fmt.Errorf("unknown input file type")
+	}
+	return sudok, nil
+}
+
+func printSolution(sudok sudoku.Sudoku, solution sudoku.Solution) error {
+	lastRow := math.MinInt
+	for _, coord := range sudok.Coordinates {
+		value, ok := solution.Get(coord)
+		if !ok {
+			return fmt.Errorf("solution missing value for coordinate %v", coord)
+		}
+		if lastRow != coord.Row {
+			fmt.Println()
+			lastRow = coord.Row
+		}
+		fmt.Printf("%d", value)
+	}
+	regionSolution, ok := solution.(sudoku.RegionSolution)
+	if sudok.RegionCount == 0 || !ok {
+		return nil
+	}
+	// print the regions that were found as letters below the values
+	fmt.Println()
+	lastRow = math.MinInt
+	for _, coord := range sudok.Coordinates {
+		region, ok := regionSolution.Region(coord)
+		if !ok {
+			return fmt.Errorf("solution missing region for coordinate %v", coord)
+		}
+		if lastRow != coord.Row {
+			fmt.Println()
+			lastRow = coord.Row
 		}
+		fmt.Printf("%c", 'A'+rune(region))
 	}
+	return nil
 }
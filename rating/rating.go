@@ -0,0 +1,158 @@
+package rating
+
+import (
+	"context"
+	"fmt"
+	"sudoku-solver/backtrack"
+	"sudoku-solver/logic"
+	"sudoku-solver/sudoku"
+)
+
+// UnsolvedScore is the score of sudokus that the logical solver can't solve
+// with the techniques it knows.
+const UnsolvedScore = 10.0
+
+// scores holds the score of every technique on the scale of Sudoku
+// Explainer. Techniques that it doesn't know are placed next to the ones of
+// similar difficulty.
+var scores = map[logic.Technique]float64{
+	logic.TechniqueFullHouse:        1.0,
+	logic.TechniqueHiddenSingle:     1.5,
+	logic.TechniqueConstraint:       2.0,
+	logic.TechniqueNakedSingle:      2.3,
+	logic.TechniqueLockedCandidates: 2.6,
+	logic.TechniqueNakedPair:        3.0,
+	logic.TechniqueXWing:            3.2,
+	logic.TechniqueHiddenPair:       3.4,
+	logic.TechniqueNakedTriple:      3.6,
+	logic.TechniqueSwordfish:        3.8,
+	logic.TechniqueHiddenTriple:     4.0,
+	logic.TechniqueXYWing:           4.2,
+	logic.TechniqueNakedQuad:        5.0,
+	logic.TechniqueJellyfish:        5.2,
+	logic.TechniqueHiddenQuad:       5.4,
+	logic.TechniqueSimpleColoring:   6.2,
+	logic.TechniqueChain:            6.6,
+}
+
+// StepScore returns the score of a step. Chains with more than five links
+// get 0.1 more for every two additional links.
+func StepScore(step logic.Step) float64 {
+	score, ok := scores[step.Technique]
+	if !ok {
+		return UnsolvedScore
+	}
+	if step.Technique == logic.TechniqueChain {
+		links := len(step.Cells) - 1
+		if links > 5 {
+			score += 0.1 * float64((links-4)/2)
+		}
+	}
+	return score
+}
+
+// Level is a band of scores that is used to present the difficulty to
+// players.
+type Level string
+
+const (
+	// LevelEasy only needs full houses and hidden singles.
+	LevelEasy Level = "easy"
+	// LevelMedium also needs naked singles, variant constraints and locked
+	// candidates.
+	LevelMedium Level = "medium"
+	// LevelHard needs subsets, x-wings, swordfish or xy-wings.
+	LevelHard Level = "hard"
+	// LevelExpert needs quads, jellyfish, coloring or chains.
+	LevelExpert Level = "expert"
+	// LevelExtreme can't be solved without guessing by the logical solver.
+	LevelExtreme Level = "extreme"
+)
+
+// Levels returns all levels from the easiest to the hardest.
+func Levels() []Level {
+	return []Level{LevelEasy, LevelMedium, LevelHard, LevelExpert, LevelExtreme}
+}
+
+// LevelOf returns the level of a score.
+func LevelOf(score float64) Level {
+	switch {
+	case score < 2.0:
+		return LevelEasy
+	case score < 3.0:
+		return LevelMedium
+	case score < 4.5:
+		return LevelHard
+	case score < UnsolvedScore:
+		return LevelExpert
+	default:
+		return LevelExtreme
+	}
+}
+
+// Rating describes how hard a sudoku is.
+type Rating struct {
+	// Score is the score of the hardest step the logical solver needs or
+	// UnsolvedScore if it gets stuck.
+	Score float64
+	// Hardest is the technique of the hardest step. It is empty if the
+	// sudoku has no empty cells.
+	Hardest logic.Technique
+	// Solved is true if the logical solver solved the sudoku.
+	Solved bool
+	// Steps is the number of steps the logical solver took.
+	Steps int
+	// Guesses is the number of times ModePencilMarkMRV had to guess to find
+	// all solutions.
+	Guesses int
+	// Candidates is the number of candidates ModePencilMarkMRV visited to
+	// find all solutions.
+	Candidates int
+}
+
+// Level returns the level of the score.
+func (r Rating) Level() Level {
+	return LevelOf(r.Score)
+}
+
+// Rate solves the sudoku with the logical solver and with ModePencilMarkMRV.
+// It returns an error if the sudoku doesn't have a unique solution or the
+// context is cancelled. The uniqueness is checked first, so sudokus with many
+// solutions are rejected without searching all of them.
+func Rate(ctx context.Context, sudok sudoku.Sudoku) (Rating, error) {
+	count, _, err := backtrack.CountSolutions(ctx, backtrack.ModePencilMarkMRV, sudok, 2)
+	if err != nil {
+		return Rating{}, fmt.Errorf("count solutions: %w", err)
+	}
+	if count == 0 {
+		return Rating{}, fmt.Errorf("sudoku has no solution")
+	}
+	if count > 1 {
+		return Rating{}, fmt.Errorf("sudoku has more than one solution")
+	}
+	stats, err := backtrack.MeasureSearch(ctx, backtrack.ModePencilMarkMRV, sudok)
+	if err != nil {
+		return Rating{}, fmt.Errorf("measure search: %w", err)
+	}
+	rating := Rating{
+		Guesses:    stats.Guesses,
+		Candidates: stats.Candidates,
+	}
+
+	steps, grid, err := logic.SolveSudoku(sudok)
+	if err != nil {
+		return Rating{}, fmt.Errorf("solve logically: %w", err)
+	}
+	rating.Steps = len(steps)
+	rating.Solved = grid.IsSolved()
+	for _, step := range steps {
+		if score := StepScore(step); score > rating.Score {
+			rating.Score = score
+			rating.Hardest = step.Technique
+		}
+	}
+	if !rating.Solved {
+		rating.Score = UnsolvedScore
+	}
+	return rating, nil
+}
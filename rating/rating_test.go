@@ -0,0 +1,104 @@
+package rating_test
+
+import (
+	"context"
+	"strings"
+	"sudoku-solver/logic"
+	"sudoku-solver/rating"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseLine(t *testing.T, line string) sudoku.Sudoku {
+	rows := make([]string, 0, 9)
+	for i := 0; i < 81; i += 9 {
+		rows = append(rows, strings.ReplaceAll(line[i:i+9], ".", "-"))
+	}
+	sudok, err := sudokuio.ParseString(strings.Join(rows, "\n"))
+	require.NoError(t, err)
+	return *sudok
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		puzzle  string
+		level   rating.Level
+		hardest logic.Technique
+	}{
+		{
+			name:    "locked candidates",
+			puzzle:  "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......",
+			level:   rating.LevelMedium,
+			hardest: logic.TechniqueLockedCandidates,
+		},
+		{
+			name:    "naked pair",
+			puzzle:  "24.....86..3......1....25..59..1...2..7...3..8...4..97..58....3......6..32.....19",
+			level:   rating.LevelHard,
+			hardest: logic.TechniqueNakedPair,
+		},
+		{
+			name:   "hardest",
+			puzzle: "8..........36......7..9.2...5...7.......457.....1...3...1....68..85...1..9....4..",
+			level:  rating.LevelExtreme,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := rating.Rate(context.Background(), parseLine(t, test.puzzle))
+			require.NoError(t, err)
+			assert.Equal(t, test.level, r.Level())
+			if test.level == rating.LevelExtreme {
+				assert.False(t, r.Solved)
+				assert.Equal(t, rating.UnsolvedScore, r.Score)
+				assert.Greater(t, r.Guesses, 0)
+				return
+			}
+			assert.True(t, r.Solved)
+			assert.Greater(t, r.Steps, 0)
+			assert.Equal(t, test.hardest, r.Hardest)
+		})
+	}
+}
+
+func TestRateRejectsSudokuWithoutUniqueSolution(t *testing.T) {
+	// two 2s in the first row
+	_, err := rating.Rate(context.Background(), parseLine(t, "2.2.............................................................................."))
+	assert.Error(t, err)
+	// the classic sudoku without its first two givens
+	_, err = rating.Rate(context.Background(), parseLine(t, ".......86..3......1....25..59..1...2..7...3..8...4..97..58....3......6..32.....19"))
+	assert.Error(t, err)
+}
+
+func TestStepScore(t *testing.T) {
+	// every technique is at least as hard as the ones the solver tries before
+	previous := 0.0
+	for _, technique := range logic.Techniques() {
+		score := rating.StepScore(logic.Step{Technique: technique})
+		assert.Less(t, score, rating.UnsolvedScore, technique)
+		if technique != logic.TechniqueConstraint && technique != logic.TechniqueNakedSingle {
+			assert.GreaterOrEqual(t, score, previous, technique)
+			previous = score
+		}
+	}
+
+	short := logic.Step{Technique: logic.TechniqueChain, Cells: make([]sudoku.Coordinate, 4)}
+	long := logic.Step{Technique: logic.TechniqueChain, Cells: make([]sudoku.Coordinate, 10)}
+	assert.Less(t, rating.StepScore(short), rating.StepScore(long))
+}
+
+func TestRateRejectsAmbiguousSudokuQuickly(t *testing.T) {
+	// a single given leaves an enormous number of solutions, which can't all
+	// be searched before the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := rating.Rate(ctx, parseLine(t, strings.Repeat(".", 80)+"1"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
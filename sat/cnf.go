@@ -0,0 +1,78 @@
+package sat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Literal is a variable or its negation in DIMACS notation: the variables are
+// numbered from 1 and a negative number is the negation of the variable.
+type Literal int
+
+func (l Literal) Negate() Literal {
+	return -l
+}
+
+// Variable returns the number of the variable of the literal.
+func (l Literal) Variable() int {
+	if l < 0 {
+		return int(-l)
+	}
+	return int(l)
+}
+
+// CNF is a formula in conjunctive normal form: every clause needs at least one
+// true literal.
+type CNF struct {
+	Variables int
+	Clauses   [][]Literal
+}
+
+// NewVariable adds a variable to the formula and returns its positive literal.
+func (f *CNF) NewVariable() Literal {
+	f.Variables++
+	return Literal(f.Variables)
+}
+
+// AddClause adds a clause to the formula. A clause without literals can't be
+// satisfied.
+func (f *CNF) AddClause(literals ...Literal) {
+	f.Clauses = append(f.Clauses, literals)
+}
+
+// AtMostOne adds the clauses that allow at most one of the literals to be
+// true.
+func (f *CNF) AtMostOne(literals ...Literal) {
+	for i := range literals {
+		for j := i + 1; j < len(literals); j++ {
+			f.AddClause(literals[i].Negate(), literals[j].Negate())
+		}
+	}
+}
+
+// ExactlyOne adds the clauses that require exactly one of the literals to be
+// true.
+func (f *CNF) ExactlyOne(literals ...Literal) {
+	f.AddClause(literals...)
+	f.AtMostOne(literals...)
+}
+
+// WriteDIMACS writes the formula in the DIMACS CNF format.
+func (f *CNF) WriteDIMACS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "p cnf %d %d\n", f.Variables, len(f.Clauses)); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for _, clause := range f.Clauses {
+		for _, literal := range clause {
+			if _, err := fmt.Fprintf(bw, "%d ", literal); err != nil {
+				return fmt.Errorf("write clause: %w", err)
+			}
+		}
+		if _, err := fmt.Fprintln(bw, "0"); err != nil {
+			return fmt.Errorf("write clause: %w", err)
+		}
+	}
+	return bw.Flush()
+}
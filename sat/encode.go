@@ -0,0 +1,372 @@
+package sat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+)
+
+// maxEnumeratedAssignments limits the number of partial assignments that are
+// enumerated to encode a constraint without a dedicated encoding.
+const maxEnumeratedAssignments = 1 << 20
+
+// Encoding is a sudoku encoded as CNF. Every coordinate has one variable per
+// possible value that is true if the coordinate has that value. Sum and
+// cardinality constraints use auxiliary variables for partial sums.
+type Encoding struct {
+	CNF
+	sudok sudoku.Sudoku
+	// cells holds the variable of every value of every coordinate in the
+	// order of the possible values.
+	cells map[sudoku.Coordinate][]Literal
+	// truth is a variable that is always true.
+	truth Literal
+}
+
+// Encode encodes the sudoku as CNF. Sudokus with unknown regions can't be
+// encoded.
+func Encode(sudok sudoku.Sudoku) (*Encoding, error) {
+	if sudok.RegionCount > 0 {
+		return nil, fmt.Errorf("sudokus with unknown regions can't be encoded")
+	}
+	e := &Encoding{
+		sudok: sudok,
+		cells: make(map[sudoku.Coordinate][]Literal, len(sudok.Coordinates)),
+	}
+	for _, coord := range sudok.Coordinates {
+		variables := make([]Literal, len(sudok.PossibleValues))
+		for i := range variables {
+			variables[i] = e.NewVariable()
+		}
+		e.cells[coord] = variables
+		e.ExactlyOne(variables...)
+	}
+	e.truth = e.NewVariable()
+	e.AddClause(e.truth)
+
+	for _, constr := range sudok.Constraints {
+		if err := e.encodeConstraint(constr); err != nil {
+			return nil, fmt.Errorf("encode %T: %w", constr, err)
+		}
+	}
+	return e, nil
+}
+
+// Variable returns the variable that is true if the coordinate has the value
+// and false if the coordinate can't have the value.
+func (e *Encoding) Variable(coordinate sudoku.Coordinate, value int) (Literal, bool) {
+	variables, ok := e.cells[coordinate]
+	if !ok {
+		return 0, false
+	}
+	for i, possible := range e.sudok.PossibleValues {
+		if possible == value {
+			return variables[i], true
+		}
+	}
+	return 0, false
+}
+
+// Decode reads the solution from the values of the variables.
+func (e *Encoding) Decode(value func(Literal) bool) sudoku.Solution {
+	solution := make(map[sudoku.Coordinate]int, len(e.sudok.Coordinates))
+	for _, coord := range e.sudok.Coordinates {
+		for i, variable := range e.cells[coord] {
+			if value(variable) {
+				solution[coord] = e.sudok.PossibleValues[i]
+				break
+			}
+		}
+	}
+	return sudoku.MapSolution(solution)
+}
+
+// Blocking returns a clause that forbids the values of the solution.
+func (e *Encoding) Blocking(solution sudoku.Solution) []Literal {
+	clause := make([]Literal, 0, len(e.sudok.Coordinates))
+	for _, coord := range e.sudok.Coordinates {
+		value, ok := solution.Get(coord)
+		if !ok {
+			continue
+		}
+		if variable, ok := e.Variable(coord, value); ok {
+			clause = append(clause, variable.Negate())
+		}
+	}
+	return clause
+}
+
+// WriteDIMACS writes the formula in the DIMACS CNF format. Comment lines
+// before the formula name the variable of every value of every coordinate,
+// e.g. "c R1C2=3 12".
+func (e *Encoding) WriteDIMACS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, coord := range e.sudok.Coordinates {
+		for i, variable := range e.cells[coord] {
+			if _, err := fmt.Fprintf(bw, "c R%dC%d=%d %d\n", coord.Row, coord.Col, e.sudok.PossibleValues[i], variable); err != nil {
+				return fmt.Errorf("write comment: %w", err)
+			}
+		}
+	}
+	if err := e.CNF.WriteDIMACS(bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (e *Encoding) encodeConstraint(constr sudoku.Constraint) error {
+	switch constr := constr.(type) {
+	case constraint.NoRepeatConstraint:
+		return e.encodeNoRepeat(constr)
+	case *constraint.NoRepeatConstraint:
+		return e.encodeNoRepeat(*constr)
+	case constraint.FixedValueConstraint:
+		return e.encodeFixedValue(constr)
+	case *constraint.FixedValueConstraint:
+		return e.encodeFixedValue(*constr)
+	case constraint.SameSumConstraint:
+		return e.encodeSameSum(constr)
+	case *constraint.SameSumConstraint:
+		return e.encodeSameSum(*constr)
+	case constraint.SumConstraint:
+		return e.encodeSum(constr)
+	case *constraint.SumConstraint:
+		return e.encodeSum(*constr)
+	case constraint.CardinalityConstraint:
+		return e.encodeCardinality(constr)
+	case *constraint.CardinalityConstraint:
+		return e.encodeCardinality(*constr)
+	default:
+		return e.encodeByEnumeration(constr)
+	}
+}
+
+func (e *Encoding) encodeNoRepeat(constr constraint.NoRepeatConstraint) error {
+	for _, value := range e.sudok.PossibleValues {
+		variables := make([]Literal, 0, len(constr.Coordinates))
+		for _, coord := range constr.Coordinates {
+			variable, ok := e.Variable(coord, value)
+			if !ok {
+				return fmt.Errorf("coordinate %v is not part of the sudoku", coord)
+			}
+			variables = append(variables, variable)
+		}
+		if len(constr.Coordinates) == len(e.sudok.PossibleValues) {
+			// a full house contains every value, which helps the solver
+			e.ExactlyOne(variables...)
+		} else {
+			e.AtMostOne(variables...)
+		}
+	}
+	return nil
+}
+
+func (e *Encoding) encodeFixedValue(constr constraint.FixedValueConstraint) error {
+	if _, ok := e.cells[constr.Coordinate]; !ok {
+		return fmt.Errorf("coordinate %v is not part of the sudoku", constr.Coordinate)
+	}
+	variable, ok := e.Variable(constr.Coordinate, constr.Value)
+	if !ok {
+		// the value is not possible, so the sudoku has no solution
+		e.AddClause()
+		return nil
+	}
+	e.AddClause(variable)
+	return nil
+}
+
+// weightedLiteral is an option for a term of a sum: if the literal is true,
+// the term has the weight.
+type weightedLiteral struct {
+	literal Literal
+	weight  int
+}
+
+// sumVariables returns a variable for every sum the terms can have that is
+// true if the terms have that sum. Exactly one option of every term has to
+// be true. The variables for the partial sums of the first k terms are
+// implied by the partial sums of the first k-1 terms and at most one of them
+// can be true, so exactly the variable of the actual sum is true.
+func (e *Encoding) sumVariables(terms [][]weightedLiteral) map[int]Literal {
+	sums := map[int]Literal{0: e.truth}
+	for _, options := range terms {
+		next := make(map[int]Literal)
+		order := make([]Literal, 0)
+		for _, sum := range sortedSums(sums) {
+			sumVariable := sums[sum]
+			for _, option := range options {
+				nextVariable, ok := next[sum+option.weight]
+				if !ok {
+					nextVariable = e.NewVariable()
+					next[sum+option.weight] = nextVariable
+					order = append(order, nextVariable)
+				}
+				e.AddClause(sumVariable.Negate(), option.literal.Negate(), nextVariable)
+			}
+		}
+		e.AtMostOne(order...)
+		sums = next
+	}
+	return sums
+}
+
+// sortedSums returns the sums in ascending order, so that the encoding is the
+// same every time.
+func sortedSums(sums map[int]Literal) []int {
+	sorted := make([]int, 0, len(sums))
+	for sum := range sums {
+		sorted = append(sorted, sum)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+func (e *Encoding) valueTerms(coordinates []sudoku.Coordinate) ([][]weightedLiteral, error) {
+	terms := make([][]weightedLiteral, 0, len(coordinates))
+	for _, coord := range coordinates {
+		variables, ok := e.cells[coord]
+		if !ok {
+			return nil, fmt.Errorf("coordinate %v is not part of the sudoku", coord)
+		}
+		options := make([]weightedLiteral, 0, len(variables))
+		for i, variable := range variables {
+			options = append(options, weightedLiteral{literal: variable, weight: e.sudok.PossibleValues[i]})
+		}
+		terms = append(terms, options)
+	}
+	return terms, nil
+}
+
+func (e *Encoding) encodeSameSum(constr constraint.SameSumConstraint) error {
+	terms1, err := e.valueTerms(constr.Coordinates1)
+	if err != nil {
+		return err
+	}
+	terms2, err := e.valueTerms(constr.Coordinates2)
+	if err != nil {
+		return err
+	}
+	sums1, sums2 := e.sumVariables(terms1), e.sumVariables(terms2)
+	e.implySameSum(sums1, sums2)
+	e.implySameSum(sums2, sums1)
+	return nil
+}
+
+// encodeSum forbids every sum of the coordinates but the required one.
+func (e *Encoding) encodeSum(constr constraint.SumConstraint) error {
+	terms, err := e.valueTerms(constr.Coordinates)
+	if err != nil {
+		return err
+	}
+	sums := e.sumVariables(terms)
+	if _, ok := sums[constr.Sum]; !ok {
+		// the sum can't be reached, so the sudoku has no solution
+		e.AddClause()
+		return nil
+	}
+	for _, sum := range sortedSums(sums) {
+		if sum != constr.Sum {
+			e.AddClause(sums[sum].Negate())
+		}
+	}
+	return nil
+}
+
+// implySameSum adds the clauses that require the sum of the second group to
+// be the sum of the first group.
+func (e *Encoding) implySameSum(sums1, sums2 map[int]Literal) {
+	for _, sum := range sortedSums(sums1) {
+		variable := sums1[sum]
+		if other, ok := sums2[sum]; ok {
+			e.AddClause(variable.Negate(), other)
+		} else {
+			e.AddClause(variable.Negate())
+		}
+	}
+}
+
+func (e *Encoding) encodeCardinality(constr constraint.CardinalityConstraint) error {
+	values := make([]int, 0, len(constr.Counts))
+	for value := range constr.Counts {
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	for _, value := range values {
+		count := constr.Counts[value]
+		terms := make([][]weightedLiteral, 0, len(constr.Coordinates))
+		for _, coord := range constr.Coordinates {
+			if _, ok := e.cells[coord]; !ok {
+				return fmt.Errorf("coordinate %v is not part of the sudoku", coord)
+			}
+			variable, ok := e.Variable(coord, value)
+			if !ok {
+				// the value is not possible, so this cell never counts
+				continue
+			}
+			terms = append(terms, []weightedLiteral{{literal: variable, weight: 1}, {literal: variable.Negate(), weight: 0}})
+		}
+		sums := e.sumVariables(terms)
+		if variable, ok := sums[count]; ok {
+			e.AddClause(variable)
+		} else {
+			e.AddClause()
+		}
+	}
+	return nil
+}
+
+// encodeByEnumeration encodes any constraint by enumerating the values of its
+// coordinates and forbidding every partial assignment that violates it.
+func (e *Encoding) encodeByEnumeration(constr sudoku.Constraint) error {
+	coordinates := constr.ConstrainedCoordinates()
+	for _, coord := range coordinates {
+		if _, ok := e.cells[coord]; !ok {
+			return fmt.Errorf("coordinate %v is not part of the sudoku", coord)
+		}
+	}
+	assignment := make(map[sudoku.Coordinate]int, len(coordinates))
+	visited := 0
+	var enumerate func(i int) error
+	enumerate = func(i int) error {
+		visited++
+		if visited > maxEnumeratedAssignments {
+			return fmt.Errorf("more than %d assignments to enumerate", maxEnumeratedAssignments)
+		}
+		if constr.IsViolated(sudoku.MapSolution(assignment)) {
+			clause := make([]Literal, 0, len(assignment))
+			for _, coord := range coordinates[:i] {
+				value, ok := assignment[coord]
+				if !ok {
+					continue
+				}
+				variable, _ := e.Variable(coord, value)
+				if !slices.Contains(clause, variable.Negate()) {
+					clause = append(clause, variable.Negate())
+				}
+			}
+			e.AddClause(clause...)
+			return nil
+		}
+		if i == len(coordinates) {
+			return nil
+		}
+		coord := coordinates[i]
+		if _, ok := assignment[coord]; ok {
+			// the coordinate is listed twice
+			return enumerate(i + 1)
+		}
+		for _, value := range e.sudok.PossibleValues {
+			assignment[coord] = value
+			if err := enumerate(i + 1); err != nil {
+				return err
+			}
+		}
+		delete(assignment, coord)
+		return nil
+	}
+	return enumerate(0)
+}
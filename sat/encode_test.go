@@ -0,0 +1,130 @@
+package sat_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sat"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeClassicSudoku(t *testing.T) {
+	sudok, err := sudokuio.ParseString(`
+		24- --- -86
+		--3 --- ---
+		1-- --2 5--
+		59- -1- --2
+		--7 --- 3--
+		8-- -4- -97
+		--5 8-- --3
+		--- --- 6--
+		32- --- -19`)
+	require.NoError(t, err)
+	encoding, err := sat.Encode(*sudok)
+	require.NoError(t, err)
+
+	solver := sat.NewSolver(encoding.CNF)
+	satisfiable, err := solver.Solve(context.Background())
+	require.NoError(t, err)
+	require.True(t, satisfiable)
+	solution := encoding.Decode(solver.Value)
+	require.NoError(t, sudok.Check(solution))
+
+	// the sudoku has a unique solution
+	solver.AddClause(encoding.Blocking(solution)...)
+	satisfiable, err = solver.Solve(context.Background())
+	require.NoError(t, err)
+	assert.False(t, satisfiable)
+}
+
+func TestEncodeVariantConstraints(t *testing.T) {
+	coordinates := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 1, Col: 3}}
+	arrow, err := constraint.NewArrowConstraint(coordinates[2], coordinates[:2])
+	require.NoError(t, err)
+	expression, err := constraint.NewExpressionConstraint("R1C1 > R1C2")
+	require.NoError(t, err)
+	sudok := sudoku.Sudoku{
+		Coordinates:    coordinates,
+		PossibleValues: []int{1, 2, 3, 4},
+		Constraints: []sudoku.Constraint{
+			constraint.NoRepeatConstraint{Coordinates: coordinates},
+			arrow,
+			expression,
+		},
+	}
+	encoding, err := sat.Encode(sudok)
+	require.NoError(t, err)
+
+	// the only solutions are 2 1 3 and 3 1 4
+	solver := sat.NewSolver(encoding.CNF)
+	solutions := 0
+	for {
+		satisfiable, err := solver.Solve(context.Background())
+		require.NoError(t, err)
+		if !satisfiable {
+			break
+		}
+		solution := encoding.Decode(solver.Value)
+		require.NoError(t, sudok.Check(solution))
+		solutions++
+		solver.AddClause(encoding.Blocking(solution)...)
+	}
+	assert.Equal(t, 2, solutions)
+}
+
+func TestWriteDIMACS(t *testing.T) {
+	coordinates := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}}
+	sudok := sudoku.Sudoku{
+		Coordinates:    coordinates,
+		PossibleValues: []int{1, 2},
+		Constraints: []sudoku.Constraint{
+			constraint.NoRepeatConstraint{Coordinates: coordinates},
+			constraint.FixedValueConstraint{Coordinate: coordinates[0], Value: 2},
+		},
+	}
+	encoding, err := sat.Encode(sudok)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, encoding.WriteDIMACS(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{"c R1C1=1 1", "c R1C1=2 2", "c R1C2=1 3", "c R1C2=2 4"}, lines[:4])
+	assert.Equal(t, "p cnf 5 10", lines[4])
+	assert.Equal(t, "2 0", lines[len(lines)-1])
+}
+
+func TestEncodeSum(t *testing.T) {
+	coordinates := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 1, Col: 3}}
+	sudok := sudoku.Sudoku{
+		Coordinates:    coordinates,
+		PossibleValues: []int{1, 2, 3, 4},
+		Constraints: []sudoku.Constraint{
+			constraint.NoRepeatConstraint{Coordinates: coordinates},
+			constraint.SumConstraint{Coordinates: coordinates, Sum: 7},
+		},
+	}
+	encoding, err := sat.Encode(sudok)
+	require.NoError(t, err)
+
+	// 1 2 4 in any order
+	solver := sat.NewSolver(encoding.CNF)
+	solutions := 0
+	for {
+		satisfiable, err := solver.Solve(context.Background())
+		require.NoError(t, err)
+		if !satisfiable {
+			break
+		}
+		solution := encoding.Decode(solver.Value)
+		require.NoError(t, sudok.Check(solution))
+		solutions++
+		solver.AddClause(encoding.Blocking(solution)...)
+	}
+	assert.Equal(t, 6, solutions)
+}
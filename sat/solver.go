@@ -0,0 +1,366 @@
+package sat
+
+import (
+	"context"
+	"fmt"
+)
+
+// restartBase is the number of conflicts between restarts, multiplied with
+// the Luby sequence.
+const restartBase = 100
+
+// Solver is a conflict driven clause learning (CDCL) SAT solver. It uses two
+// watched literals for unit propagation, learns a clause at the first unique
+// implication point of every conflict, jumps back to the second highest level
+// of the learned clause and branches on the variable with the highest
+// activity. Clauses can be added between calls to Solve, e.g. to block a
+// solution that was found already.
+//
+// Internally the literal of variable v is 2v and its negation is 2v+1.
+type Solver struct {
+	variables int
+	clauses   [][]int
+	// watches holds the clauses that watch a literal, i.e. that have it in
+	// one of their first two positions
+	watches [][]int
+
+	// assignment is 1 if a variable is true, -1 if it is false and 0 if it is
+	// not assigned
+	assignment []int8
+	level      []int
+	// reason is the clause that implied the value of a variable or -1 for
+	// decisions
+	reason []int
+	// phase is the value a variable had last, which is tried first when
+	// branching on it again
+	phase    []bool
+	activity []float64
+	bump     float64
+
+	trail []int
+	// trailLimits holds the length of the trail at the start of every
+	// decision level
+	trailLimits []int
+	propagated  int
+
+	// pendingUnits are unit clauses that are assigned at the next Solve
+	pendingUnits  []int
+	unsatisfiable bool
+	seen          []bool
+}
+
+// NewSolver creates a solver for the formula.
+func NewSolver(formula CNF) *Solver {
+	s := &Solver{
+		variables:  formula.Variables,
+		watches:    make([][]int, 2*(formula.Variables+1)),
+		assignment: make([]int8, formula.Variables+1),
+		level:      make([]int, formula.Variables+1),
+		reason:     make([]int, formula.Variables+1),
+		phase:      make([]bool, formula.Variables+1),
+		activity:   make([]float64, formula.Variables+1),
+		bump:       1,
+		seen:       make([]bool, formula.Variables+1),
+	}
+	for _, clause := range formula.Clauses {
+		s.AddClause(clause...)
+	}
+	return s
+}
+
+func internalLiteral(l Literal) int {
+	if l < 0 {
+		return 2*int(-l) + 1
+	}
+	return 2 * int(l)
+}
+
+func (s *Solver) literalValue(l int) int8 {
+	value := s.assignment[l>>1]
+	if l&1 == 1 {
+		return -value
+	}
+	return value
+}
+
+// Value returns the value of the literal in the last solution that was found.
+func (s *Solver) Value(l Literal) bool {
+	return s.literalValue(internalLiteral(l)) > 0
+}
+
+// AddClause adds a clause to the formula. It undoes all decisions of the last
+// call to Solve.
+func (s *Solver) AddClause(literals ...Literal) {
+	s.cancelUntil(0)
+	clause := make([]int, 0, len(literals))
+	for _, literal := range literals {
+		if literal.Variable() == 0 || literal.Variable() > s.variables {
+			panic(fmt.Sprintf("literal %d is not part of the formula", literal))
+		}
+		l := internalLiteral(literal)
+		switch {
+		case s.literalValue(l) > 0:
+			// the clause is satisfied forever
+			return
+		case s.literalValue(l) < 0:
+			continue
+		}
+		duplicate, tautology := false, false
+		for _, other := range clause {
+			duplicate = duplicate || other == l
+			tautology = tautology || other == l^1
+		}
+		if tautology {
+			return
+		}
+		if !duplicate {
+			clause = append(clause, l)
+		}
+	}
+	switch len(clause) {
+	case 0:
+		s.unsatisfiable = true
+	case 1:
+		s.pendingUnits = append(s.pendingUnits, clause[0])
+	default:
+		s.attach(clause)
+	}
+}
+
+func (s *Solver) attach(clause []int) int {
+	index := len(s.clauses)
+	s.clauses = append(s.clauses, clause)
+	s.watches[clause[0]] = append(s.watches[clause[0]], index)
+	s.watches[clause[1]] = append(s.watches[clause[1]], index)
+	return index
+}
+
+func (s *Solver) decisionLevel() int {
+	return len(s.trailLimits)
+}
+
+func (s *Solver) assign(l int, reason int) {
+	v := l >> 1
+	if l&1 == 1 {
+		s.assignment[v] = -1
+	} else {
+		s.assignment[v] = 1
+	}
+	s.level[v] = s.decisionLevel()
+	s.reason[v] = reason
+	s.trail = append(s.trail, l)
+}
+
+func (s *Solver) cancelUntil(level int) {
+	if s.decisionLevel() <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trailLimits[level]; i-- {
+		v := s.trail[i] >> 1
+		s.phase[v] = s.assignment[v] > 0
+		s.assignment[v] = 0
+	}
+	s.trail = s.trail[:s.trailLimits[level]]
+	s.trailLimits = s.trailLimits[:level]
+	s.propagated = len(s.trail)
+}
+
+// propagate assigns the literals implied by unit clauses and returns the
+// index of a conflicting clause or -1.
+func (s *Solver) propagate() int {
+	for s.propagated < len(s.trail) {
+		falseLiteral := s.trail[s.propagated] ^ 1
+		s.propagated++
+		watching := s.watches[falseLiteral]
+		kept := watching[:0]
+		conflict := -1
+		for i, index := range watching {
+			if conflict >= 0 {
+				kept = append(kept, watching[i:]...)
+				break
+			}
+			clause := s.clauses[index]
+			if clause[0] == falseLiteral {
+				clause[0], clause[1] = clause[1], clause[0]
+			}
+			if s.literalValue(clause[0]) > 0 {
+				kept = append(kept, index)
+				continue
+			}
+			moved := false
+			for k := 2; k < len(clause); k++ {
+				if s.literalValue(clause[k]) >= 0 {
+					clause[1], clause[k] = clause[k], clause[1]
+					s.watches[clause[1]] = append(s.watches[clause[1]], index)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+			kept = append(kept, index)
+			if s.literalValue(clause[0]) < 0 {
+				conflict = index
+				continue
+			}
+			s.assign(clause[0], index)
+		}
+		s.watches[falseLiteral] = kept
+		if conflict >= 0 {
+			return conflict
+		}
+	}
+	return -1
+}
+
+// analyze learns a clause from the conflict that is asserting at the first
+// unique implication point. It returns the clause with the asserting literal
+// first and the level to jump back to.
+func (s *Solver) analyze(conflict int) ([]int, int) {
+	learned := []int{-1}
+	pending := 0
+	p := -1
+	index := len(s.trail) - 1
+	for {
+		clause := s.clauses[conflict]
+		start := 0
+		if p >= 0 {
+			start = 1
+		}
+		for _, q := range clause[start:] {
+			v := q >> 1
+			if s.seen[v] || s.level[v] == 0 {
+				continue
+			}
+			s.bumpActivity(v)
+			s.seen[v] = true
+			if s.level[v] >= s.decisionLevel() {
+				pending++
+			} else {
+				learned = append(learned, q)
+			}
+		}
+		for !s.seen[s.trail[index]>>1] {
+			index--
+		}
+		p = s.trail[index]
+		index--
+		conflict = s.reason[p>>1]
+		s.seen[p>>1] = false
+		pending--
+		if pending == 0 {
+			break
+		}
+	}
+	learned[0] = p ^ 1
+
+	backjump := 0
+	for i := 1; i < len(learned); i++ {
+		s.seen[learned[i]>>1] = false
+		if s.level[learned[i]>>1] > backjump {
+			backjump = s.level[learned[i]>>1]
+			learned[1], learned[i] = learned[i], learned[1]
+		}
+	}
+	return learned, backjump
+}
+
+func (s *Solver) bumpActivity(v int) {
+	s.activity[v] += s.bump
+	if s.activity[v] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] *= 1e-100
+		}
+		s.bump *= 1e-100
+	}
+}
+
+// pickBranch returns the unassigned variable with the highest activity or 0
+// if all variables are assigned.
+func (s *Solver) pickBranch() int {
+	best := 0
+	for v := 1; v <= s.variables; v++ {
+		if s.assignment[v] != 0 {
+			continue
+		}
+		if best == 0 || s.activity[v] > s.activity[best] {
+			best = v
+		}
+	}
+	return best
+}
+
+// luby returns the i-th element (starting at 0) of the Luby sequence
+// 1 1 2 1 1 2 4 1 1 2 ...
+func luby(i int) int {
+	size, exponent := 1, 0
+	for size < i+1 {
+		exponent++
+		size = 2*size + 1
+	}
+	for size-1 != i {
+		size = (size - 1) / 2
+		exponent--
+		i %= size
+	}
+	return 1 << exponent
+}
+
+// Solve searches an assignment that satisfies all clauses. It returns false
+// if there is none and an error if the context is cancelled.
+func (s *Solver) Solve(ctx context.Context) (bool, error) {
+	if s.unsatisfiable {
+		return false, nil
+	}
+	s.cancelUntil(0)
+	for _, unit := range s.pendingUnits {
+		switch s.literalValue(unit) {
+		case 0:
+			s.assign(unit, -1)
+		case -1:
+			s.unsatisfiable = true
+			return false, nil
+		}
+	}
+	s.pendingUnits = s.pendingUnits[:0]
+
+	restarts, conflicts := 0, 0
+	for {
+		conflict := s.propagate()
+		if conflict >= 0 {
+			if s.decisionLevel() == 0 {
+				s.unsatisfiable = true
+				return false, nil
+			}
+			learned, backjump := s.analyze(conflict)
+			s.cancelUntil(backjump)
+			if len(learned) == 1 {
+				s.assign(learned[0], -1)
+			} else {
+				s.assign(learned[0], s.attach(learned))
+			}
+			s.bump *= 1.05
+			conflicts++
+			if conflicts >= restartBase*luby(restarts) {
+				if err := ctx.Err(); err != nil {
+					return false, err
+				}
+				restarts++
+				conflicts = 0
+				s.cancelUntil(0)
+			}
+			continue
+		}
+		v := s.pickBranch()
+		if v == 0 {
+			return true, nil
+		}
+		s.trailLimits = append(s.trailLimits, len(s.trail))
+		if s.phase[v] {
+			s.assign(2*v, -1)
+		} else {
+			s.assign(2*v+1, -1)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package sat_test
+
+import (
+	"context"
+	"sudoku-solver/sat"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// satisfiedBy returns true if every clause has a true literal.
+func satisfiedBy(formula sat.CNF, value func(sat.Literal) bool) bool {
+	for _, clause := range formula.Clauses {
+		satisfied := false
+		for _, literal := range clause {
+			satisfied = satisfied || value(literal)
+		}
+		if !satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+// bruteForce returns true if any assignment satisfies the formula.
+func bruteForce(formula sat.CNF) bool {
+	for assignment := 0; assignment < 1<<formula.Variables; assignment++ {
+		value := func(l sat.Literal) bool {
+			isTrue := assignment&(1<<(l.Variable()-1)) != 0
+			if l < 0 {
+				return !isTrue
+			}
+			return isTrue
+		}
+		if satisfiedBy(formula, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSolverMatchesBruteForce(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		formula := sat.CNF{Variables: rapid.IntRange(1, 8).Draw(t, "variables")}
+		clauses := rapid.IntRange(0, 40).Draw(t, "clauses")
+		for i := 0; i < clauses; i++ {
+			literals := rapid.SliceOfN(rapid.IntRange(1, formula.Variables), 1, 3).Draw(t, "clause")
+			clause := make([]sat.Literal, 0, len(literals))
+			for _, variable := range literals {
+				literal := sat.Literal(variable)
+				if rapid.Bool().Draw(t, "negate") {
+					literal = literal.Negate()
+				}
+				clause = append(clause, literal)
+			}
+			formula.AddClause(clause...)
+		}
+
+		solver := sat.NewSolver(formula)
+		satisfiable, err := solver.Solve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, bruteForce(formula), satisfiable)
+		if satisfiable {
+			assert.True(t, satisfiedBy(formula, solver.Value))
+		}
+	})
+}
+
+func TestSolverPigeonholeIsUnsatisfiable(t *testing.T) {
+	// 6 pigeons in 5 holes, variable p*5+h+1 puts pigeon p in hole h
+	const pigeons, holes = 6, 5
+	formula := sat.CNF{}
+	variables := make([][]sat.Literal, pigeons)
+	for p := range variables {
+		variables[p] = make([]sat.Literal, holes)
+		for h := range variables[p] {
+			variables[p][h] = formula.NewVariable()
+		}
+		formula.AddClause(variables[p]...)
+	}
+	for h := 0; h < holes; h++ {
+		inHole := make([]sat.Literal, 0, pigeons)
+		for p := 0; p < pigeons; p++ {
+			inHole = append(inHole, variables[p][h])
+		}
+		formula.AtMostOne(inHole...)
+	}
+
+	satisfiable, err := sat.NewSolver(formula).Solve(context.Background())
+	require.NoError(t, err)
+	assert.False(t, satisfiable)
+}
+
+func TestSolverEnumeratesWithBlockingClauses(t *testing.T) {
+	formula := sat.CNF{}
+	a, b, c := formula.NewVariable(), formula.NewVariable(), formula.NewVariable()
+	formula.ExactlyOne(a, b, c)
+	solver := sat.NewSolver(formula)
+
+	found := 0
+	for {
+		satisfiable, err := solver.Solve(context.Background())
+		require.NoError(t, err)
+		if !satisfiable {
+			break
+		}
+		found++
+		blocking := make([]sat.Literal, 0, 3)
+		for _, literal := range []sat.Literal{a, b, c} {
+			if solver.Value(literal) {
+				blocking = append(blocking, literal.Negate())
+			} else {
+				blocking = append(blocking, literal)
+			}
+		}
+		solver.AddClause(blocking...)
+	}
+	assert.Equal(t, 3, found)
+}
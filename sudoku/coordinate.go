@@ -19,6 +19,17 @@ func (c Coordinate) String() string {
 	return fmt.Sprintf("R%d-C%d", c.Row, c.Col)
 }
 
+// OrthogonalNeighbours returns the four coordinates next to this one. They are
+// not necessarily part of a sudoku.
+func (c Coordinate) OrthogonalNeighbours() []Coordinate {
+	return []Coordinate{
+		{Row: c.Row - 1, Col: c.Col},
+		{Row: c.Row, Col: c.Col + 1},
+		{Row: c.Row + 1, Col: c.Col},
+		{Row: c.Row, Col: c.Col - 1},
+	}
+}
+
 func ParseCoordinateString(s string) (Coordinate, error) {
 	s = strings.ToUpper(s)
 	// check that the coordinate matches the regex
@@ -0,0 +1,87 @@
+package sudoku
+
+// ConstraintIndex maps every coordinate of a sudoku to the constraints that
+// constrain it, so that a change of a single cell only needs to look at the
+// constraints of that cell. The index doesn't change when the constraints of
+// the sudoku are changed later, so it has to be rebuilt in that case.
+type ConstraintIndex struct {
+	constraints []Constraint
+	// positions holds the positions in constraints of the constraints of
+	// every coordinate, in ascending order
+	positions map[Coordinate][]int
+	// constraintsOf holds the same constraints as positions
+	constraintsOf map[Coordinate][]Constraint
+}
+
+// ConstraintIndex builds the index of the constraints of the sudoku. The
+// constraints of every coordinate keep the order of the sudoku.
+func (s Sudoku) ConstraintIndex() *ConstraintIndex {
+	index := &ConstraintIndex{
+		constraints:   s.Constraints,
+		positions:     make(map[Coordinate][]int, len(s.Coordinates)),
+		constraintsOf: make(map[Coordinate][]Constraint, len(s.Coordinates)),
+	}
+	for position, constraint := range s.Constraints {
+		for _, coordinate := range constraint.ConstrainedCoordinates() {
+			positions := index.positions[coordinate]
+			// constraints that list a coordinate more than once are only
+			// indexed once for it
+			if len(positions) > 0 && positions[len(positions)-1] == position {
+				continue
+			}
+			index.positions[coordinate] = append(positions, position)
+			index.constraintsOf[coordinate] = append(index.constraintsOf[coordinate], constraint)
+		}
+	}
+	return index
+}
+
+// ConstraintsOf returns the constraints that constrain the coordinate.
+func (i *ConstraintIndex) ConstraintsOf(coordinate Coordinate) []Constraint {
+	return i.constraintsOf[coordinate]
+}
+
+// Positions returns the positions in the constraints of the sudoku of the
+// constraints that constrain the coordinate.
+func (i *ConstraintIndex) Positions(coordinate Coordinate) []int {
+	return i.positions[coordinate]
+}
+
+// Constraint returns the constraint at the position.
+func (i *ConstraintIndex) Constraint(position int) Constraint {
+	return i.constraints[position]
+}
+
+// Len returns the number of constraints.
+func (i *ConstraintIndex) Len() int {
+	return len(i.constraints)
+}
+
+// IsViolatedAt returns true if one of the constraints of the given coordinates
+// is violated. If the solution didn't violate any constraint before the given
+// coordinates were filled in, this is the same as checking all constraints.
+func (i *ConstraintIndex) IsViolatedAt(solution Solution, coordinates ...Coordinate) bool {
+	if len(coordinates) == 1 {
+		for _, constraint := range i.constraintsOf[coordinates[0]] {
+			if constraint.IsViolated(solution) {
+				return true
+			}
+		}
+		return false
+	}
+	// a constraint can constrain several of the coordinates, but only needs to
+	// be checked once
+	checked := make([]bool, len(i.constraints))
+	for _, coordinate := range coordinates {
+		for _, position := range i.positions[coordinate] {
+			if checked[position] {
+				continue
+			}
+			checked[position] = true
+			if i.constraints[position].IsViolated(solution) {
+				return true
+			}
+		}
+	}
+	return false
+}
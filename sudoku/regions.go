@@ -0,0 +1,112 @@
+package sudoku
+
+import "fmt"
+
+// RegionSolution is a Solution that also assigns the coordinates to the
+// unknown regions of a sudoku. Regions are numbered from 0 to RegionCount-1.
+type RegionSolution interface {
+	Solution
+	Region(Coordinate) (int, bool)
+}
+
+// RegionSize returns the number of cells every unknown region has to contain.
+func (s Sudoku) RegionSize() int {
+	if s.RegionCount <= 0 {
+		return 0
+	}
+	return len(s.Coordinates) / s.RegionCount
+}
+
+// regionsViolated returns true if the regions assigned so far are already
+// invalid, i.e. a region is too big or contains a value twice. Solutions that
+// don't assign regions never violate them.
+func (s Sudoku) regionsViolated(solution Solution) bool {
+	regionSolution, ok := solution.(RegionSolution)
+	if !ok {
+		return false
+	}
+	size := s.RegionSize()
+	counts := make(map[int]int)
+	seen := make(map[[2]int]struct{})
+	for _, coordinate := range s.Coordinates {
+		region, ok := regionSolution.Region(coordinate)
+		if !ok {
+			continue
+		}
+		if region < 0 || region >= s.RegionCount {
+			return true
+		}
+		counts[region]++
+		if counts[region] > size {
+			return true
+		}
+		value, ok := regionSolution.Get(coordinate)
+		if !ok {
+			continue
+		}
+		key := [2]int{region, value}
+		if _, ok := seen[key]; ok {
+			return true
+		}
+		seen[key] = struct{}{}
+	}
+	return false
+}
+
+// checkRegions returns an error if the solution does not divide all
+// coordinates into valid regions.
+func (s Sudoku) checkRegions(solution Solution) error {
+	if len(s.Coordinates)%s.RegionCount != 0 {
+		return fmt.Errorf("%d coordinates can't be divided into %d regions", len(s.Coordinates), s.RegionCount)
+	}
+	regionSolution, ok := solution.(RegionSolution)
+	if !ok {
+		return fmt.Errorf("solution does not assign regions")
+	}
+	if s.regionsViolated(solution) {
+		return fmt.Errorf("a region is too big or repeats a value")
+	}
+	members := make(map[int][]Coordinate, s.RegionCount)
+	for _, coordinate := range s.Coordinates {
+		region, ok := regionSolution.Region(coordinate)
+		if !ok {
+			return fmt.Errorf("coordinate %v is not assigned to a region", coordinate)
+		}
+		members[region] = append(members[region], coordinate)
+	}
+	for region := 0; region < s.RegionCount; region++ {
+		if len(members[region]) != s.RegionSize() {
+			return fmt.Errorf("region %d has %d instead of %d cells", region, len(members[region]), s.RegionSize())
+		}
+		if !IsConnected(members[region]) {
+			return fmt.Errorf("region %d is not orthogonally connected", region)
+		}
+	}
+	return nil
+}
+
+// IsConnected returns true if the coordinates form one orthogonally
+// connected area.
+func IsConnected(coordinates []Coordinate) bool {
+	if len(coordinates) == 0 {
+		return true
+	}
+	inArea := make(map[Coordinate]bool, len(coordinates))
+	for _, coordinate := range coordinates {
+		inArea[coordinate] = true
+	}
+	reached := map[Coordinate]bool{coordinates[0]: true}
+	queue := []Coordinate{coordinates[0]}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, neighbour := range current.OrthogonalNeighbours() {
+			if !inArea[neighbour] || reached[neighbour] {
+				continue
+			}
+			reached[neighbour] = true
+			queue = append(queue, neighbour)
+		}
+	}
+	return len(reached) == len(inArea)
+}
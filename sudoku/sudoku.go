@@ -7,10 +7,54 @@ type Constraint interface {
 	ConstrainedCoordinates() []Coordinate
 }
 
+// Propagator is implemented by constraints that can narrow down the values
+// their coordinates can still have during a search.
+type Propagator interface {
+	// Propagate removes the values from the domains of the constrained
+	// coordinates that can't be part of a solution anymore. It returns an
+	// error if the constraint can no longer be satisfied.
+	Propagate(Domains) error
+}
+
+// Supporter is implemented by constraints that can enumerate the values of a
+// coordinate that have support, i.e. that can be extended to values for all
+// constrained coordinates that satisfy the constraint. It may return values
+// without support, but never leaves out a value with support.
+type Supporter interface {
+	// Supported returns the values of the coordinate that still have
+	// support given the current domains.
+	Supported(domains Domains, coordinate Coordinate) []int
+}
+
+// Domains holds the values every coordinate can still have during a search.
+// A coordinate that is filled in only has its value.
+type Domains interface {
+	Solution
+	// Candidates returns the values the coordinate can still have in
+	// ascending order.
+	Candidates(Coordinate) []int
+	// CanBe returns true if the coordinate can still have the value.
+	CanBe(Coordinate, int) bool
+	// Bounds returns the smallest and the biggest value the coordinate can
+	// still have.
+	Bounds(Coordinate) (int, int)
+	// Restrict only keeps the values of the coordinate for which keep returns
+	// true. It returns an error if no value is left. Filled in coordinates
+	// keep their value, but an error is returned if keep rejects it.
+	Restrict(coordinate Coordinate, keep func(int) bool) error
+}
+
 type Sudoku struct {
 	Coordinates    []Coordinate
 	PossibleValues []int
 	Constraints    []Constraint
+
+	// RegionCount is the number of regions with unknown shape the coordinates
+	// have to be divided into (chaos construction). Every region has to be
+	// orthogonally connected, contain the same number of cells and no value
+	// more than once. The region of every coordinate is part of the solution,
+	// see RegionSolution. Zero means that there are no unknown regions.
+	RegionCount int
 }
 
 type Solution interface {
@@ -34,6 +78,9 @@ func (s Sudoku) IsViolated(solution Solution) bool {
 			return true
 		}
 	}
+	if s.RegionCount > 0 && s.regionsViolated(solution) {
+		return true
+	}
 	return false
 }
 
@@ -46,6 +93,9 @@ func (s Sudoku) IsSolved(solution Solution) bool {
 			return false
 		}
 	}
+	if s.RegionCount > 0 && s.checkRegions(solution) != nil {
+		return false
+	}
 	return true
 }
 
@@ -60,5 +110,10 @@ func (s Sudoku) Check(solution Solution) error {
 			return fmt.Errorf("solution is missing coordinate %v", coordinate)
 		}
 	}
+	if s.RegionCount > 0 {
+		if err := s.checkRegions(solution); err != nil {
+			return fmt.Errorf("solution has invalid regions: %w", err)
+		}
+	}
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
+	"strconv"
 	"sudoku-solver/constraint"
 	"sudoku-solver/sudoku"
 )
@@ -18,8 +19,17 @@ type constraintType string
 
 const (
 	constraintTypeNormalSudokuRules constraintType = "normalSudokuRules"
+	constraintTypeRowColumnRules    constraintType = "rowColumnRules"
 	constraintTypeArrow             constraintType = "arrow"
 	constraintTypeFixedValues       constraintType = "fixedValues"
+	constraintTypeEqualSum          constraintType = "equalSum"
+	constraintTypeExpression        constraintType = "expression"
+	constraintTypeMinimum           constraintType = "minimum"
+	constraintTypeMaximum           constraintType = "maximum"
+	constraintTypeCardinality       constraintType = "cardinality"
+	constraintTypeKillerCage        constraintType = "killerCage"
+	constraintTypeThermometer       constraintType = "thermometer"
+	constraintTypeAntiKnight        constraintType = "antiKnight"
 )
 
 type baseConstraintGen struct {
@@ -35,6 +45,17 @@ func generateNormalSudokuRules(sudok sudoku.Sudoku) ([]sudoku.Constraint, error)
 	for _, c := range boxConstraints {
 		constraints = append(constraints, c)
 	}
+	rowColumnConstraints, err := generateRowColumnRules(sudok)
+	if err != nil {
+		return nil, err
+	}
+	return append(constraints, rowColumnConstraints...), nil
+}
+
+// generateRowColumnRules generates the rules of a sudoku without boxes, e.g.
+// for chaos constructions whose regions are not known.
+func generateRowColumnRules(sudok sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	var constraints []sudoku.Constraint
 	rowConstraints, err := constraint.RowConstraints(sudok.Coordinates)
 	if err != nil {
 		return nil, fmt.Errorf("generate row constraints: %w", err)
@@ -78,6 +99,175 @@ func (g arrowConstraintGen) generate(s sudoku.Sudoku) ([]sudoku.Constraint, erro
 	return []sudoku.Constraint{*arrow}, nil
 }
 
+type equalSumConstraintGen struct {
+	Coordinates1 []RawCoordinate `json:"coordinates1"`
+	Coordinates2 []RawCoordinate `json:"coordinates2"`
+}
+
+func (g equalSumConstraintGen) generate(s sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	coordinates1, err := sudokuCoordinates(s, g.Coordinates1)
+	if err != nil {
+		return nil, fmt.Errorf("first equal sum group: %w", err)
+	}
+	coordinates2, err := sudokuCoordinates(s, g.Coordinates2)
+	if err != nil {
+		return nil, fmt.Errorf("second equal sum group: %w", err)
+	}
+	sameSum, err := constraint.NewSameSumConstraint(coordinates1, coordinates2)
+	if err != nil {
+		return nil, fmt.Errorf("invalid equal sum constraint: %w", err)
+	}
+	return []sudoku.Constraint{*sameSum}, nil
+}
+
+type expressionConstraintGen struct {
+	Expression string `json:"expression"`
+}
+
+func (g expressionConstraintGen) generate(s sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	expression, err := constraint.NewExpressionConstraint(g.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression constraint: %w", err)
+	}
+	for _, coord := range expression.ConstrainedCoordinates() {
+		if !slices.Contains(s.Coordinates, coord) {
+			return nil, fmt.Errorf("expression coordinate %s is not in the sudoku", coord)
+		}
+	}
+	return []sudoku.Constraint{*expression}, nil
+}
+
+// extremumConstraintGen generates the constraints for minimum or maximum cells.
+type extremumConstraintGen struct {
+	Coordinates []RawCoordinate `json:"coordinates"`
+
+	newConstraints func(sudoku.Coordinate, []sudoku.Coordinate) ([]constraint.LessThanConstraint, error)
+}
+
+func (g extremumConstraintGen) generate(s sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	cells, err := sudokuCoordinates(s, g.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+	if len(cells) == 0 {
+		return nil, fmt.Errorf("at least one coordinate is required")
+	}
+	constraints := make([]sudoku.Constraint, 0)
+	for _, cell := range cells {
+		lessThans, err := g.newConstraints(cell, s.Coordinates)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range lessThans {
+			constraints = append(constraints, c)
+		}
+	}
+	return constraints, nil
+}
+
+// cardinalityConstraintGen generates a constraint that counts how often values
+// appear in a group of cells. Each requires every possible value of the sudoku
+// to appear that often, Counts sets (or overrides) the count of single values.
+type cardinalityConstraintGen struct {
+	Coordinates []RawCoordinate `json:"coordinates"`
+	Each        *int            `json:"each"`
+	Counts      map[string]int  `json:"counts"`
+}
+
+func (g cardinalityConstraintGen) generate(s sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	coordinates, err := sudokuCoordinates(s, g.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+	if g.Each == nil && len(g.Counts) == 0 {
+		return nil, fmt.Errorf("cardinality constraint needs each or counts")
+	}
+	counts := make(map[int]int)
+	if g.Each != nil {
+		for _, value := range s.PossibleValues {
+			counts[value] = *g.Each
+		}
+	}
+	for valueStr, count := range g.Counts {
+		value, err := strconv.Atoi(valueStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", valueStr, err)
+		}
+		if !slices.Contains(s.PossibleValues, value) {
+			return nil, fmt.Errorf("value %d is not allowed in the sudoku", value)
+		}
+		counts[value] = count
+	}
+	cardinality, err := constraint.NewCardinalityConstraint(coordinates, counts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cardinality constraint: %w", err)
+	}
+	return []sudoku.Constraint{*cardinality}, nil
+}
+
+// killerCageConstraintGen generates the constraints of a cage whose values
+// don't repeat and add up to Sum.
+type killerCageConstraintGen struct {
+	Coordinates []RawCoordinate `json:"coordinates"`
+	Sum         int             `json:"sum"`
+}
+
+func (g killerCageConstraintGen) generate(s sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	coordinates, err := sudokuCoordinates(s, g.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+	noRepeat, sum, err := constraint.NewKillerCageConstraints(coordinates, g.Sum)
+	if err != nil {
+		return nil, fmt.Errorf("invalid killer cage constraint: %w", err)
+	}
+	return []sudoku.Constraint{*noRepeat, *sum}, nil
+}
+
+// thermometerConstraintGen generates the constraints of a thermometer whose
+// bulb is the first coordinate.
+type thermometerConstraintGen struct {
+	Coordinates []RawCoordinate `json:"coordinates"`
+}
+
+func (g thermometerConstraintGen) generate(s sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	coordinates, err := sudokuCoordinates(s, g.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+	lessThans, err := constraint.NewThermometerConstraints(coordinates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid thermometer constraint: %w", err)
+	}
+	constraints := make([]sudoku.Constraint, 0, len(lessThans))
+	for _, c := range lessThans {
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+func generateAntiKnight(s sudoku.Sudoku) ([]sudoku.Constraint, error) {
+	constraints := make([]sudoku.Constraint, 0)
+	for _, c := range constraint.AntiKnightConstraints(s.Coordinates) {
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+// sudokuCoordinates converts the raw coordinates and checks that all of them
+// are part of the sudoku.
+func sudokuCoordinates(s sudoku.Sudoku, raw []RawCoordinate) ([]sudoku.Coordinate, error) {
+	coordinates := make([]sudoku.Coordinate, 0, len(raw))
+	for _, c := range raw {
+		coord := sudoku.Coordinate(c)
+		if !slices.Contains(s.Coordinates, coord) {
+			return nil, fmt.Errorf("coordinate %s is not in the sudoku", coord)
+		}
+		coordinates = append(coordinates, coord)
+	}
+	return coordinates, nil
+}
+
 type fixedValuesConstraintGen struct {
 	Values map[string]int `json:"values"`
 }
@@ -115,6 +305,9 @@ func (c *RawConstraintGen) UnmarshalJSON(data []byte) error {
 	case constraintTypeNormalSudokuRules:
 		*c = generateNormalSudokuRules
 		return nil
+	case constraintTypeRowColumnRules:
+		*c = generateRowColumnRules
+		return nil
 	case constraintTypeArrow:
 		var arrowGen arrowConstraintGen
 		if err := json.Unmarshal(data, &arrowGen); err != nil {
@@ -129,6 +322,54 @@ func (c *RawConstraintGen) UnmarshalJSON(data []byte) error {
 		}
 		*c = fixedValuesGen.generate
 		return nil
+	case constraintTypeEqualSum:
+		var equalSumGen equalSumConstraintGen
+		if err := json.Unmarshal(data, &equalSumGen); err != nil {
+			return fmt.Errorf("invalid equal sum constraint: %w", err)
+		}
+		*c = equalSumGen.generate
+		return nil
+	case constraintTypeExpression:
+		var expressionGen expressionConstraintGen
+		if err := json.Unmarshal(data, &expressionGen); err != nil {
+			return fmt.Errorf("invalid expression constraint: %w", err)
+		}
+		*c = expressionGen.generate
+		return nil
+	case constraintTypeMinimum, constraintTypeMaximum:
+		extremumGen := extremumConstraintGen{newConstraints: constraint.NewMinimumConstraints}
+		if base.Type == constraintTypeMaximum {
+			extremumGen.newConstraints = constraint.NewMaximumConstraints
+		}
+		if err := json.Unmarshal(data, &extremumGen); err != nil {
+			return fmt.Errorf("invalid %s constraint: %w", base.Type, err)
+		}
+		*c = extremumGen.generate
+		return nil
+	case constraintTypeCardinality:
+		var cardinalityGen cardinalityConstraintGen
+		if err := json.Unmarshal(data, &cardinalityGen); err != nil {
+			return fmt.Errorf("invalid cardinality constraint: %w", err)
+		}
+		*c = cardinalityGen.generate
+		return nil
+	case constraintTypeKillerCage:
+		var killerCageGen killerCageConstraintGen
+		if err := json.Unmarshal(data, &killerCageGen); err != nil {
+			return fmt.Errorf("invalid killer cage constraint: %w", err)
+		}
+		*c = killerCageGen.generate
+		return nil
+	case constraintTypeThermometer:
+		var thermometerGen thermometerConstraintGen
+		if err := json.Unmarshal(data, &thermometerGen); err != nil {
+			return fmt.Errorf("invalid thermometer constraint: %w", err)
+		}
+		*c = thermometerGen.generate
+		return nil
+	case constraintTypeAntiKnight:
+		*c = generateAntiKnight
+		return nil
 	default:
 		return fmt.Errorf("unknown constraint type %s", base.Type)
 	}
@@ -11,6 +11,13 @@ type RawCoordinate sudoku.Coordinate
 
 var _ json.Unmarshaler = (*RawCoordinate)(nil)
 
+var _ json.Marshaler = RawCoordinate{}
+
+// MarshalJSON writes the coordinate as a string of the form "R1C1".
+func (c RawCoordinate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("R%dC%d", c.Row, c.Col))
+}
+
 func (c *RawCoordinate) UnmarshalJSON(data []byte) error {
 	var s string
 	if err := json.Unmarshal(data, &s); err != nil {
@@ -14,6 +14,7 @@ type fieldType string
 
 const (
 	fieldTypeNormal fieldType = "normal"
+	fieldTypeChaos  fieldType = "chaos"
 )
 
 type baseFieldGen struct {
@@ -24,6 +25,12 @@ type normalFieldGen struct {
 	Rows []string
 }
 
+// chaosFieldGen generates a field whose regions are not given. The field has
+// to be divided into as many regions as it has rows.
+type chaosFieldGen struct {
+	Rows []string
+}
+
 func (g *RawFieldGen) UnmarshalJSON(data []byte) error {
 	var base baseFieldGen
 	if err := json.Unmarshal(data, &base); err != nil {
@@ -37,6 +44,13 @@ func (g *RawFieldGen) UnmarshalJSON(data []byte) error {
 		}
 		*g = normalGen.generate
 		return nil
+	case fieldTypeChaos:
+		var chaosGen chaosFieldGen
+		if err := json.Unmarshal(data, &chaosGen); err != nil {
+			return fmt.Errorf("parse chaos field: %w", err)
+		}
+		*g = chaosGen.generate
+		return nil
 	default:
 		return fmt.Errorf("unknown sudoku type %q", base.Type)
 	}
@@ -45,3 +59,12 @@ func (g *RawFieldGen) UnmarshalJSON(data []byte) error {
 func (n normalFieldGen) generate() (*sudoku.Sudoku, error) {
 	return StringRowsToSudoku(n.Rows)
 }
+
+func (c chaosFieldGen) generate() (*sudoku.Sudoku, error) {
+	sudok, err := StringRowsToSudoku(c.Rows)
+	if err != nil {
+		return nil, err
+	}
+	sudok.RegionCount = len(c.Rows)
+	return sudok, nil
+}
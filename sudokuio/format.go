@@ -0,0 +1,232 @@
+package sudokuio
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+)
+
+// JSONSudoku is a sudoku in the JSON format that ParseJSON reads.
+type JSONSudoku struct {
+	Field       JSONField        `json:"field"`
+	Constraints []JSONConstraint `json:"constraints"`
+}
+
+// JSONField is the field of a JSONSudoku. Its rows hold the fixed values and
+// '-' for empty cells.
+type JSONField struct {
+	Type string   `json:"type"`
+	Rows []string `json:"rows"`
+}
+
+// JSONConstraint is a constraint of a JSONSudoku. Only the fields its type
+// reads are set.
+type JSONConstraint struct {
+	Type         string          `json:"type"`
+	Coordinates  []RawCoordinate `json:"coordinates,omitempty"`
+	Sum          int             `json:"sum,omitempty"`
+	Circle       *RawCoordinate  `json:"circle,omitempty"`
+	Path         []RawCoordinate `json:"path,omitempty"`
+	Coordinates1 []RawCoordinate `json:"coordinates1,omitempty"`
+	Coordinates2 []RawCoordinate `json:"coordinates2,omitempty"`
+	Expression   string          `json:"expression,omitempty"`
+	Each         *int            `json:"each,omitempty"`
+	Counts       map[string]int  `json:"counts,omitempty"`
+	Values       map[string]int  `json:"values,omitempty"`
+}
+
+// ParseJSONSudoku reads a sudoku in the format of ParseJSON without creating
+// its constraints, so that it can be changed and written again. It only
+// checks that the JSON has the right shape; Sudoku reports invalid values.
+func ParseJSONSudoku(bytes []byte) (JSONSudoku, error) {
+	var description JSONSudoku
+	if err := json.Unmarshal(bytes, &description); err != nil {
+		return JSONSudoku{}, fmt.Errorf("parse json: %w", err)
+	}
+	return description, nil
+}
+
+// IsVariant returns true if the constraint is neither the rows, columns and
+// boxes nor fixed values.
+func (c JSONConstraint) IsVariant() bool {
+	switch constraintType(c.Type) {
+	case constraintTypeNormalSudokuRules, constraintTypeRowColumnRules, constraintTypeFixedValues:
+		return false
+	default:
+		return true
+	}
+}
+
+// Clues returns the values given in the rows of the field followed by the
+// values of the fixedValues constraints in the order of their coordinates.
+func (s JSONSudoku) Clues() ([]constraint.FixedValueConstraint, error) {
+	var clues []constraint.FixedValueConstraint
+	for rowIdx, row := range s.Field.Rows {
+		values, err := ParseStringRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("parse %d. row: %w", rowIdx+1, err)
+		}
+		for colIdx, value := range values {
+			if value != 0 {
+				coord := sudoku.Coordinate{Row: rowIdx + 1, Col: colIdx + 1}
+				clues = append(clues, constraint.FixedValueConstraint{Coordinate: coord, Value: value})
+			}
+		}
+	}
+	for _, c := range s.Constraints {
+		if constraintType(c.Type) != constraintTypeFixedValues {
+			continue
+		}
+		var fixed []constraint.FixedValueConstraint
+		for coordStr, value := range c.Values {
+			coord, err := sudoku.ParseCoordinateString(coordStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coordinate %s: %w", coordStr, err)
+			}
+			fixed = append(fixed, constraint.FixedValueConstraint{Coordinate: coord, Value: value})
+		}
+		slices.SortFunc(fixed, func(a, b constraint.FixedValueConstraint) int {
+			if a.Coordinate.Row != b.Coordinate.Row {
+				return a.Coordinate.Row - b.Coordinate.Row
+			}
+			return a.Coordinate.Col - b.Coordinate.Col
+		})
+		clues = append(clues, fixed...)
+	}
+	return clues, nil
+}
+
+// WithoutClue returns a copy of the sudoku in which the coordinate is empty
+// in the rows and left out of the fixedValues constraints. A fixedValues
+// constraint without values is dropped. The sudoku itself is not changed.
+func (s JSONSudoku) WithoutClue(coord sudoku.Coordinate) JSONSudoku {
+	reduced := JSONSudoku{Field: JSONField{Type: s.Field.Type, Rows: slices.Clone(s.Field.Rows)}}
+	if coord.Row >= 1 && coord.Row <= len(reduced.Field.Rows) {
+		reduced.Field.Rows[coord.Row-1] = withoutValue(reduced.Field.Rows[coord.Row-1], coord.Col)
+	}
+	for _, c := range s.Constraints {
+		if constraintType(c.Type) != constraintTypeFixedValues {
+			reduced.Constraints = append(reduced.Constraints, c)
+			continue
+		}
+		values := make(map[string]int, len(c.Values))
+		for coordStr, value := range c.Values {
+			if parsed, err := sudoku.ParseCoordinateString(coordStr); err != nil || parsed != coord {
+				values[coordStr] = value
+			}
+		}
+		if len(values) > 0 {
+			c.Values = values
+			reduced.Constraints = append(reduced.Constraints, c)
+		}
+	}
+	return reduced
+}
+
+// withoutValue replaces the value of the column in the row with '-' and keeps
+// the spaces of the row.
+func withoutValue(row string, col int) string {
+	runes := []rune(row)
+	cell := 0
+	for i, r := range runes {
+		if r == ' ' {
+			continue
+		}
+		cell++
+		if cell == col {
+			runes[i] = '-'
+			break
+		}
+	}
+	return string(runes)
+}
+
+// NormalSudokuRulesJSON returns the constraint for the rows, columns and boxes.
+func NormalSudokuRulesJSON() JSONConstraint {
+	return JSONConstraint{Type: string(constraintTypeNormalSudokuRules)}
+}
+
+// KillerCageJSON returns the constraint for a killer cage.
+func KillerCageJSON(coordinates []sudoku.Coordinate, sum int) JSONConstraint {
+	return JSONConstraint{Type: string(constraintTypeKillerCage), Coordinates: rawCoordinates(coordinates), Sum: sum}
+}
+
+// ThermometerJSON returns the constraint for a thermometer whose bulb is the
+// first coordinate.
+func ThermometerJSON(coordinates []sudoku.Coordinate) JSONConstraint {
+	return JSONConstraint{Type: string(constraintTypeThermometer), Coordinates: rawCoordinates(coordinates)}
+}
+
+// AntiKnightJSON returns the constraint that cells a knight's move apart
+// don't have the same value.
+func AntiKnightJSON() JSONConstraint {
+	return JSONConstraint{Type: string(constraintTypeAntiKnight)}
+}
+
+func rawCoordinates(coordinates []sudoku.Coordinate) []RawCoordinate {
+	raw := make([]RawCoordinate, 0, len(coordinates))
+	for _, coord := range coordinates {
+		raw = append(raw, RawCoordinate(coord))
+	}
+	return raw
+}
+
+// Sudoku creates the sudoku the way ParseJSON would.
+func (s JSONSudoku) Sudoku() (*sudoku.Sudoku, error) {
+	bytes, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("encode json: %w", err)
+	}
+	return ParseJSON(bytes)
+}
+
+// ClassicJSON returns a normal field with the fixed values of the sudoku and
+// the normal sudoku rules. Other constraints of the sudoku are left out.
+func ClassicJSON(sudok sudoku.Sudoku) JSONSudoku {
+	return JSONSudoku{
+		Field: JSONField{
+			Type: string(fieldTypeNormal),
+			Rows: FormatRows(sudok),
+		},
+		Constraints: []JSONConstraint{NormalSudokuRulesJSON()},
+	}
+}
+
+// FormatString formats the fixed values of the sudoku in the text format that
+// ParseString reads, one line per row.
+func FormatString(sudok sudoku.Sudoku) string {
+	return strings.Join(FormatRows(sudok), "\n") + "\n"
+}
+
+// FormatRows returns one string per row of the sudoku with the fixed value of
+// every cell and '-' for empty cells. The rows and cells are in the order of
+// the coordinates of the sudoku.
+func FormatRows(sudok sudoku.Sudoku) []string {
+	values := make(map[sudoku.Coordinate]int)
+	for _, c := range sudok.Constraints {
+		if fixed, ok := c.(constraint.FixedValueConstraint); ok {
+			values[fixed.Coordinate] = fixed.Value
+		}
+	}
+	rows := make([]string, 0)
+	var row strings.Builder
+	for i, coord := range sudok.Coordinates {
+		if i > 0 && coord.Row != sudok.Coordinates[i-1].Row {
+			rows = append(rows, row.String())
+			row.Reset()
+		}
+		if value, ok := values[coord]; ok {
+			row.WriteString(strconv.Itoa(value))
+		} else {
+			row.WriteByte('-')
+		}
+	}
+	if row.Len() > 0 {
+		rows = append(rows, row.String())
+	}
+	return rows
+}
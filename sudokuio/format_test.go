@@ -0,0 +1,128 @@
+package sudokuio_test
+
+import (
+	"encoding/json"
+	"strings"
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const formatTestSudoku = `24-----86
+--3------
+1----25--
+59--1---2
+--7---3--
+8---4--97
+--58----3
+------6--
+32-----19
+`
+
+func TestFormatStringRoundTrip(t *testing.T) {
+	sudok, err := sudokuio.ParseString(formatTestSudoku)
+	require.NoError(t, err)
+	assert.Equal(t, formatTestSudoku, sudokuio.FormatString(*sudok))
+}
+
+func TestClassicJSONRoundTrip(t *testing.T) {
+	sudok, err := sudokuio.ParseString(formatTestSudoku)
+	require.NoError(t, err)
+	bytes, err := json.Marshal(sudokuio.ClassicJSON(*sudok))
+	require.NoError(t, err)
+	parsed, err := sudokuio.ParseJSON(bytes)
+	require.NoError(t, err)
+	assert.Equal(t, sudok.Coordinates, parsed.Coordinates)
+	assert.Equal(t, sudok.PossibleValues, parsed.PossibleValues)
+	assert.ElementsMatch(t, sudok.Constraints, parsed.Constraints)
+}
+
+func TestJSONSudokuRoundTrip(t *testing.T) {
+	cage := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}}
+	thermometer := []sudoku.Coordinate{{Row: 5, Col: 5}, {Row: 4, Col: 6}, {Row: 3, Col: 6}}
+	description := sudokuio.JSONSudoku{
+		Field: sudokuio.JSONField{Type: "normal", Rows: strings.Split(strings.TrimSpace(formatTestSudoku), "\n")},
+		Constraints: []sudokuio.JSONConstraint{
+			sudokuio.NormalSudokuRulesJSON(),
+			sudokuio.KillerCageJSON(cage, 6),
+			sudokuio.ThermometerJSON(thermometer),
+			sudokuio.AntiKnightJSON(),
+		},
+	}
+	bytes, err := json.Marshal(description)
+	require.NoError(t, err)
+	assert.Contains(t, string(bytes), `{"type":"killerCage","coordinates":["R1C1","R1C2"],"sum":6}`)
+
+	sudok, err := sudokuio.ParseJSON(bytes)
+	require.NoError(t, err)
+	assert.Contains(t, sudok.Constraints, constraint.SumConstraint{Coordinates: cage, Sum: 6})
+	assert.Contains(t, sudok.Constraints, constraint.LessThanConstraint{Smaller: thermometer[1], Bigger: thermometer[2]})
+	assert.Equal(t, formatTestSudoku, sudokuio.FormatString(*sudok))
+
+	created, err := description.Sudoku()
+	require.NoError(t, err)
+	assert.Equal(t, sudok, created)
+}
+
+func TestParseJSONSudokuRoundTrip(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{"type": "normalSudokuRules"},
+			{"type": "arrow", "circle": "R1C1", "path": ["R1C2", "R1C3"]},
+			{"type": "fixedValues", "values": {"R9C9": 1, "R9C8": 2}},
+			{"type": "equalSum", "coordinates1": ["R2C1"], "coordinates2": ["R3C1", "R3C2"]},
+			{"type": "expression", "expression": "R4C4 + R4C5 == R4C6"},
+			{"type": "minimum", "coordinates": ["R5C5", "R5C6"]},
+			{"type": "cardinality", "coordinates": ["R6C1", "R6C2", "R6C3", "R6C4"], "each": 0, "counts": {"5": 3, "1": 1}},
+			{"type": "killerCage", "coordinates": ["R7C1", "R7C2"], "sum": 5},
+			{"type": "thermometer", "coordinates": ["R8C1", "R8C2"]},
+			{"type": "antiKnight"}
+		]
+	}`
+	description, err := sudokuio.ParseJSONSudoku([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, description.Constraints, 10)
+	assert.Equal(t, "R4C4 + R4C5 == R4C6", description.Constraints[4].Expression)
+
+	expected, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	created, err := description.Sudoku()
+	require.NoError(t, err)
+	assert.Equal(t, expected.Coordinates, created.Coordinates)
+	assert.ElementsMatch(t, expected.Constraints, created.Constraints)
+}
+
+func TestJSONSudokuWithoutClue(t *testing.T) {
+	description := sudokuio.JSONSudoku{
+		Field: sudokuio.JSONField{Type: "normal", Rows: []string{
+			"24- --- -86", "--3 --- ---", "1-- --2 5--",
+			"59- -1- --2", "--7 --- 3--", "8-- -4- -97",
+			"--5 8-- --3", "--- --- 6--", "32- --- -19",
+		}},
+		Constraints: []sudokuio.JSONConstraint{
+			sudokuio.NormalSudokuRulesJSON(),
+			{Type: "fixedValues", Values: map[string]int{"R1C3": 9}},
+		},
+	}
+	clues, err := description.Clues()
+	require.NoError(t, err)
+	require.Len(t, clues, 27)
+	assert.Equal(t, constraint.FixedValueConstraint{Coordinate: sudoku.Coordinate{Row: 1, Col: 1}, Value: 2}, clues[0])
+	assert.Equal(t, constraint.FixedValueConstraint{Coordinate: sudoku.Coordinate{Row: 1, Col: 3}, Value: 9}, clues[26])
+
+	reduced := description.WithoutClue(sudoku.Coordinate{Row: 1, Col: 8})
+	assert.Equal(t, "24- --- --6", reduced.Field.Rows[0])
+	assert.Equal(t, "24- --- -86", description.Field.Rows[0])
+
+	reduced = reduced.WithoutClue(sudoku.Coordinate{Row: 1, Col: 3})
+	assert.Equal(t, []sudokuio.JSONConstraint{sudokuio.NormalSudokuRulesJSON()}, reduced.Constraints)
+	assert.Len(t, description.Constraints, 2)
+	clues, err = reduced.Clues()
+	require.NoError(t, err)
+	assert.Len(t, clues, 25)
+}
@@ -24,10 +24,6 @@ func (s rawJsonSudoku) generate() (*sudoku.Sudoku, error) {
 		if err != nil {
 			return nil, fmt.Errorf("generate constraints: %w", err)
 		}
-		for ci, c := range constraints {
-			fmt.Printf("[%d] constraint: %+v\n", ci, c)
-		}
-
 		sudok.Constraints = append(sudok.Constraints, constraints...)
 	}
 	return sudok, nil
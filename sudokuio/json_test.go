@@ -0,0 +1,238 @@
+package sudokuio_test
+
+import (
+	"sudoku-solver/constraint"
+	"sudoku-solver/sudoku"
+	"sudoku-solver/sudokuio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const emptyFieldJSON = `{
+	"type": "normal",
+	"rows": [
+		"--- --- ---",
+		"--- --- ---",
+		"--- --- ---",
+		"--- --- ---",
+		"--- --- ---",
+		"--- --- ---",
+		"--- --- ---",
+		"--- --- ---",
+		"--- --- ---"
+	]
+}`
+
+func TestParseJSONEqualSum(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "equalSum",
+				"coordinates1": ["R1C1", "R1C2"],
+				"coordinates2": ["R2C1", "R2C2", "R2C3"]
+			}
+		]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, sudok.Constraints, 1)
+	assert.Equal(t, constraint.SameSumConstraint{
+		Coordinates1: []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}},
+		Coordinates2: []sudoku.Coordinate{{Row: 2, Col: 1}, {Row: 2, Col: 2}, {Row: 2, Col: 3}},
+	}, sudok.Constraints[0])
+}
+
+func TestParseJSONEqualSumEmptyGroup(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "equalSum",
+				"coordinates1": ["R1C1"],
+				"coordinates2": []
+			}
+		]
+	}`
+	_, err := sudokuio.ParseJSON([]byte(input))
+	assert.Error(t, err)
+}
+
+func TestParseJSONExpression(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "expression",
+				"expression": "R1C1 + R1C2 == 2 * R3C3"
+			}
+		]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, sudok.Constraints, 1)
+	assert.Equal(t, []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 3, Col: 3}},
+		sudok.Constraints[0].ConstrainedCoordinates())
+}
+
+func TestParseJSONExpressionOutsideSudoku(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "expression",
+				"expression": "R1C1 < R10C1"
+			}
+		]
+	}`
+	_, err := sudokuio.ParseJSON([]byte(input))
+	assert.Error(t, err)
+}
+
+func TestParseJSONMinimumMaximum(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "minimum",
+				"coordinates": ["R1C1"]
+			},
+			{
+				"type": "maximum",
+				"coordinates": ["R5C5"]
+			}
+		]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	r1c1 := sudoku.Coordinate{Row: 1, Col: 1}
+	r5c5 := sudoku.Coordinate{Row: 5, Col: 5}
+	assert.Equal(t, []sudoku.Constraint{
+		constraint.LessThanConstraint{Smaller: r1c1, Bigger: sudoku.Coordinate{Row: 1, Col: 2}},
+		constraint.LessThanConstraint{Smaller: r1c1, Bigger: sudoku.Coordinate{Row: 2, Col: 1}},
+		constraint.LessThanConstraint{Smaller: sudoku.Coordinate{Row: 4, Col: 5}, Bigger: r5c5},
+		constraint.LessThanConstraint{Smaller: sudoku.Coordinate{Row: 5, Col: 6}, Bigger: r5c5},
+		constraint.LessThanConstraint{Smaller: sudoku.Coordinate{Row: 6, Col: 5}, Bigger: r5c5},
+		constraint.LessThanConstraint{Smaller: sudoku.Coordinate{Row: 5, Col: 4}, Bigger: r5c5},
+	}, sudok.Constraints)
+}
+
+func TestParseJSONCardinality(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "cardinality",
+				"coordinates": ["R1C1", "R1C2", "R1C3", "R1C4"],
+				"each": 0,
+				"counts": {"5": 3, "1": 1}
+			}
+		]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, sudok.Constraints, 1)
+	cardinality, ok := sudok.Constraints[0].(constraint.CardinalityConstraint)
+	require.True(t, ok)
+	assert.Equal(t, map[int]int{1: 1, 2: 0, 3: 0, 4: 0, 5: 3, 6: 0, 7: 0, 8: 0, 9: 0}, cardinality.Counts)
+}
+
+func TestParseJSONKillerCage(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "killerCage",
+				"coordinates": ["R1C1", "R1C2", "R2C2"],
+				"sum": 10
+			}
+		]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	cage := []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 2, Col: 2}}
+	assert.Equal(t, []sudoku.Constraint{
+		constraint.NoRepeatConstraint{Coordinates: cage},
+		constraint.SumConstraint{Coordinates: cage, Sum: 10},
+	}, sudok.Constraints)
+}
+
+func TestParseJSONThermometer(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [
+			{
+				"type": "thermometer",
+				"coordinates": ["R1C1", "R2C2", "R2C3"]
+			}
+		]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, []sudoku.Constraint{
+		constraint.LessThanConstraint{Smaller: sudoku.Coordinate{Row: 1, Col: 1}, Bigger: sudoku.Coordinate{Row: 2, Col: 2}},
+		constraint.LessThanConstraint{Smaller: sudoku.Coordinate{Row: 2, Col: 2}, Bigger: sudoku.Coordinate{Row: 2, Col: 3}},
+	}, sudok.Constraints)
+}
+
+func TestParseJSONAntiKnight(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [{"type": "antiKnight"}]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	assert.Len(t, sudok.Constraints, 224)
+	assert.Contains(t, sudok.Constraints, constraint.NoRepeatConstraint{
+		Coordinates: []sudoku.Coordinate{{Row: 1, Col: 1}, {Row: 2, Col: 3}},
+	})
+}
+
+func TestParseJSONChaos(t *testing.T) {
+	input := `{
+		"field": {
+			"type": "chaos",
+			"rows": [
+				"--- --- ---",
+				"--- --- ---",
+				"--- --- ---",
+				"--- --- ---",
+				"--- --- ---",
+				"--- --- ---",
+				"--- --- ---",
+				"--- --- ---",
+				"--- --- ---"
+			]
+		},
+		"constraints": []
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	assert.Len(t, sudok.Coordinates, 81)
+	assert.Equal(t, 9, sudok.RegionCount)
+}
+
+func TestParseJSONRowColumnRules(t *testing.T) {
+	input := `{
+		"field": ` + emptyFieldJSON + `,
+		"constraints": [{"type": "rowColumnRules"}]
+	}`
+	sudok, err := sudokuio.ParseJSON([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, sudok.Constraints, 18)
+	for _, c := range sudok.Constraints {
+		noRepeat, ok := c.(constraint.NoRepeatConstraint)
+		require.True(t, ok)
+		require.Len(t, noRepeat.Coordinates, 9)
+		first := noRepeat.Coordinates[0]
+		// no boxes, every constraint is a whole row or column
+		sameRow, sameCol := true, true
+		for _, coord := range noRepeat.Coordinates {
+			sameRow = sameRow && coord.Row == first.Row
+			sameCol = sameCol && coord.Col == first.Col
+		}
+		assert.True(t, sameRow || sameCol)
+	}
+}